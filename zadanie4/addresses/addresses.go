@@ -0,0 +1,80 @@
+// Package addresses validates postal addresses. Postal codes are checked
+// against the format of their country; countries without a known format
+// accept any short code of letters, digits, spaces and hyphens.
+package addresses
+
+import (
+	"regexp"
+	"strings"
+
+	"shop/models"
+)
+
+// postalCodes are the postal code formats of countries shipped to most.
+var postalCodes = map[string]*regexp.Regexp{
+	"PL": regexp.MustCompile(`^\d{2}-\d{3}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"LT": regexp.MustCompile(`^(LT-)?\d{5}$`),
+	"CZ": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"SK": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"AT": regexp.MustCompile(`^\d{4}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+}
+
+var (
+	countryPattern     = regexp.MustCompile(`^[A-Z]{2}$`)
+	genericPostalCode  = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+	phoneCharsReplacer = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+	phonePattern       = regexp.MustCompile(`^\+?\d{6,15}$`)
+)
+
+// Problems found by Validate.
+const (
+	ProblemRequired   = "required"
+	ProblemCountry    = "country"
+	ProblemPostalCode = "postal_code"
+	ProblemPhone      = "phone"
+)
+
+// Normalize trims the fields of address and upper-cases its country and
+// postal code.
+func Normalize(address *models.PostalAddress) {
+	address.Name = strings.TrimSpace(address.Name)
+	address.Company = strings.TrimSpace(address.Company)
+	address.Street = strings.TrimSpace(address.Street)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Phone = strings.TrimSpace(address.Phone)
+}
+
+// Validate returns the first problem of a normalized address, or "" when
+// it is complete. The phone number is optional.
+func Validate(address models.PostalAddress) string {
+	if address.Name == "" || address.Street == "" || address.City == "" || address.PostalCode == "" {
+		return ProblemRequired
+	}
+	if !countryPattern.MatchString(address.Country) {
+		return ProblemCountry
+	}
+	if !ValidPostalCode(address.Country, address.PostalCode) {
+		return ProblemPostalCode
+	}
+	if address.Phone != "" && !phonePattern.MatchString(phoneCharsReplacer.Replace(address.Phone)) {
+		return ProblemPhone
+	}
+	return ""
+}
+
+// ValidPostalCode reports whether code is a postal code of country.
+func ValidPostalCode(country, code string) bool {
+	if pattern, ok := postalCodes[country]; ok {
+		return pattern.MatchString(code)
+	}
+	return genericPostalCode.MatchString(code)
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"shop/models"
+	"shop/tenant"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+	ErrOtherStore      = errors.New("credentials belong to another store")
+)
+
+// Principal is the authenticated caller of a request: a user signed in with
+// a session token, or an integration using an API key. Both belong to the
+// store StoreID and may only act on it.
+type Principal struct {
+	UserID   uint
+	Role     string
+	APIKeyID uint
+	Scopes   []Permission
+	StoreID  uint
+}
+
+// Can reports whether the principal may perform an operation guarded by
+// permission. API keys are limited to their scopes.
+func (p *Principal) Can(permission Permission) bool {
+	if p == nil {
+		return false
+	}
+	if p.APIKeyID != 0 {
+		for _, scope := range p.Scopes {
+			if scope == permission {
+				return true
+			}
+		}
+		return false
+	}
+	return RoleCan(p.Role, permission)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored by Middleware, or nil for anonymous
+// requests.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Check returns ErrUnauthenticated for anonymous callers and ErrForbidden
+// for callers whose role lacks permission.
+func Check(ctx context.Context, permission Permission) error {
+	p := FromContext(ctx)
+	if p == nil {
+		return ErrUnauthenticated
+	}
+	if !p.Can(permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// CheckStore returns ErrOtherStore when the request is for a store other
+// than the principal's, so staff of one store cannot pick another with
+// X-Store.
+func CheckStore(ctx context.Context, p *Principal) error {
+	if store, ok := tenant.FromContext(ctx); ok && p != nil && p.StoreID != store.ID {
+		return ErrOtherStore
+	}
+	return nil
+}
+
+// HashPassword hashes a password for storage.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword reports whether password matches hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken returns a random token with the given prefix and the hash to
+// store for it.
+func NewToken(prefix string) (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = prefix + hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of a token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer" value.
+func BearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Authenticate resolves a session token to its principal, whatever the
+// store of db's context. It returns ErrUnauthenticated for unknown or
+// expired tokens.
+func Authenticate(db *gorm.DB, token string) (*Principal, error) {
+	var session models.Session
+	err := db.Joins("User").
+		Where("token_hash = ? AND expires_at > ?", HashToken(token), time.Now()).
+		Where(`"User"."id" IS NOT NULL`).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{UserID: session.UserID, Role: session.User.Role, StoreID: session.User.StoreID}, nil
+}
+
+// EnsureAdmin creates an admin account with email and password in the store
+// of db's context unless a user with that email already exists there. It
+// bootstraps the store's first admin, who can then assign roles through the
+// API.
+func EnsureAdmin(db *gorm.DB, email, password string) (bool, error) {
+	var count int64
+	if err := db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	user := models.User{Email: email, Name: "Administrator", Role: models.RoleAdmin, PasswordHash: hash}
+	return true, db.Create(&user).Error
+}
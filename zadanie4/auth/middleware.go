@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"shop/i18n"
+	"shop/logging"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// Middleware identifies the caller from an X-API-Key header or an
+// "Authorization: Bearer" header and stores the principal in the request
+// context. Requests without credentials continue anonymously; invalid ones
+// are rejected with 401 so clients notice an expired session or revoked key
+// instead of silently losing access, and so are credentials of a store
+// other than the request's. A principal already stored by ValidateAPIKey
+// is kept. It must run after tenant.Middleware.
+func Middleware(db *gorm.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			principal := FromContext(ctx)
+			if principal == nil {
+				var err error
+				principal, err = resolve(db.WithContext(ctx), c.Request().Header.Get(APIKeyHeader), c.Request().Header.Get(echo.HeaderAuthorization))
+				if err != nil {
+					return deny(c, err)
+				}
+				if principal == nil {
+					return next(c)
+				}
+				c.SetRequest(c.Request().WithContext(WithPrincipal(ctx, principal)))
+			}
+			if err := CheckStore(ctx, principal); err != nil {
+				return deny(c, err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// ValidateAPIKey authenticates the API key of a request for the rate
+// limiter, which runs before Middleware, and returns the key's ID. The
+// principal is stored in the request context, so Middleware does not look
+// the key up again.
+func ValidateAPIKey(db *gorm.DB) func(c echo.Context, key string) (string, bool) {
+	return func(c echo.Context, key string) (string, bool) {
+		ctx := c.Request().Context()
+		principal, err := AuthenticateAPIKey(db.WithContext(ctx), key)
+		if err != nil {
+			return "", false
+		}
+		c.SetRequest(c.Request().WithContext(WithPrincipal(ctx, principal)))
+		return strconv.FormatUint(uint64(principal.APIKeyID), 10), true
+	}
+}
+
+// Require lets the request through only when the caller has permission.
+func Require(permission Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := Check(c.Request().Context(), permission); err != nil {
+				return deny(c, err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireUser lets the request through only when a user is signed in. API
+// keys act for no user and are turned away too.
+func RequireUser() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if principal := FromContext(c.Request().Context()); principal == nil || principal.UserID == 0 {
+				return deny(c, ErrUnauthenticated)
+			}
+			return next(c)
+		}
+	}
+}
+
+func deny(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		return c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, i18n.CodeUnauthenticated))
+	case errors.Is(err, ErrForbidden):
+		return c.JSON(http.StatusForbidden, logging.ErrorBody(c, i18n.CodeForbidden))
+	case errors.Is(err, ErrOtherStore):
+		return c.JSON(http.StatusForbidden, logging.ErrorBody(c, i18n.CodeOtherStore))
+	}
+	return err
+}
+
+// resolve authenticates an API key, or failing that a bearer token. Both
+// empty means an anonymous caller: nil and no error.
+func resolve(db *gorm.DB, apiKey, authorization string) (*Principal, error) {
+	if apiKey != "" {
+		return AuthenticateAPIKey(db, apiKey)
+	}
+	if token := BearerToken(authorization); token != "" {
+		return Authenticate(db, token)
+	}
+	return nil, nil
+}
+
+// UnaryServerInterceptor authenticates gRPC calls from "x-api-key" or
+// "authorization" metadata and enforces the permissions listed per full method name, e.g.
+// "/shop.v1.CatalogService/CreateProduct". Like Middleware, it rejects
+// credentials of another store and must run after the tenant interceptor.
+func UnaryServerInterceptor(db *gorm.DB, methods map[string]Permission) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			principal, err := resolve(db.WithContext(ctx), first(md.Get("x-api-key")), first(md.Get("authorization")))
+			if err != nil {
+				return nil, grpcError(err)
+			}
+			if err := CheckStore(ctx, principal); err != nil {
+				return nil, grpcError(err)
+			}
+			if principal != nil {
+				ctx = WithPrincipal(ctx, principal)
+			}
+		}
+
+		if permission, ok := methods[info.FullMethod]; ok {
+			if err := Check(ctx, permission); err != nil {
+				return nil, grpcError(err)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrOtherStore):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, "authentication failed")
+}
@@ -0,0 +1,83 @@
+// Package auth identifies callers from bearer tokens or API keys and checks
+// their permissions against the role matrix or the key's scopes.
+package auth
+
+import "shop/models"
+
+// Permission names an operation that needs more than anonymous access.
+type Permission string
+
+const (
+	PermCatalogWrite     Permission = "catalog:write"
+	PermPromotionsManage Permission = "promotions:manage"
+	PermReviewsModerate  Permission = "reviews:moderate"
+	PermWebhooksManage   Permission = "webhooks:manage"
+	PermCacheFlush       Permission = "cache:flush"
+	PermUsersManage      Permission = "users:manage"
+	PermAPIKeysManage    Permission = "api_keys:manage"
+	PermStoresManage     Permission = "stores:manage"
+	PermTaxManage        Permission = "tax:manage"
+	PermShippingManage   Permission = "shipping:manage"
+	PermOrdersManage     Permission = "orders:manage"
+)
+
+// Permissions lists every permission, in the order they are documented.
+var Permissions = []Permission{
+	PermCatalogWrite,
+	PermPromotionsManage,
+	PermReviewsModerate,
+	PermWebhooksManage,
+	PermCacheFlush,
+	PermUsersManage,
+	PermAPIKeysManage,
+	PermStoresManage,
+	PermTaxManage,
+	PermShippingManage,
+	PermOrdersManage,
+}
+
+// Matrix lists what every role may do. Reading the catalogue and using
+// carts, wishlists and reviews needs no permission at all; checking out and
+// reading orders needs a signed-in owner or PermOrdersManage.
+var Matrix = map[string][]Permission{
+	models.RoleCustomer: {},
+	models.RoleStaff: {
+		PermCatalogWrite,
+		PermPromotionsManage,
+		PermReviewsModerate,
+		PermOrdersManage,
+	},
+	models.RoleAdmin: {
+		PermCatalogWrite,
+		PermPromotionsManage,
+		PermReviewsModerate,
+		PermWebhooksManage,
+		PermCacheFlush,
+		PermUsersManage,
+		PermAPIKeysManage,
+		PermStoresManage,
+		PermTaxManage,
+		PermShippingManage,
+		PermOrdersManage,
+	},
+}
+
+// IsValidPermission reports whether name is one of Permissions.
+func IsValidPermission(name string) bool {
+	for _, p := range Permissions {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleCan reports whether role grants permission.
+func RoleCan(role string, permission Permission) bool {
+	for _, p := range Matrix[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
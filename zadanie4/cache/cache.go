@@ -0,0 +1,155 @@
+// Package cache provides a size- and TTL-bounded in-memory LRU cache.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache keeps at most size entries, each for at most ttl, evicting the
+// least recently used entry when full. A nil *Cache is valid and caches
+// nothing, which is how caching is switched off.
+type Cache[K comparable, V any] struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	entries    map[K]*list.Element
+	order      *list.List
+	generation uint64
+	now        func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// New returns a cache holding up to size entries for ttl each, or nil when
+// size or ttl is not positive.
+func New[K comparable, V any](size int, ttl time.Duration) *Cache[K, V] {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &Cache[K, V]{
+		size:    size,
+		ttl:     ttl,
+		entries: map[K]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached under key if it has not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Load returns the cached value for key, calling load and caching its
+// result on a miss. A value loaded while the cache was purged is returned
+// but not stored, so a concurrent invalidation cannot be undone by a read
+// that started before it.
+func (c *Cache[K, V]) Load(key K, load func() (V, error)) (value V, hit bool, err error) {
+	if value, ok := c.Get(key); ok {
+		return value, true, nil
+	}
+	if c == nil {
+		value, err = load()
+		return value, false, err
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	value, err = load()
+	if err != nil {
+		return value, false, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.set(key, value)
+	}
+	c.mu.Unlock()
+	return value, false, nil
+}
+
+// Set stores value under key, evicting the least recently used entry when
+// the cache is full.
+func (c *Cache[K, V]) Set(key K, value V) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+// Purge drops every entry and returns how many there were.
+func (c *Cache[K, V]) Purge() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	c.entries = map[K]*list.Element{}
+	c.order.Init()
+	c.generation++
+	return n
+}
+
+// Len returns the number of cached entries, including expired ones not yet
+// evicted.
+func (c *Cache[K, V]) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *Cache[K, V]) set(key K, value V) {
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for len(c.entries) > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry[K, V]).key)
+}
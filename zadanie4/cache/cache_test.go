@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for the cache under test.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(size int, ttl time.Duration) (*Cache[string, int], *clock) {
+	clk := &clock{now: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	c := New[string, int](size, ttl)
+	c.now = clk.Now
+	return c, clk
+}
+
+func TestNewDisabled(t *testing.T) {
+	for _, c := range []*Cache[string, int]{New[string, int](0, time.Minute), New[string, int](10, 0)} {
+		if c != nil {
+			t.Fatal("Expected a nil cache")
+		}
+		c.Set("a", 1)
+		if _, ok := c.Get("a"); ok {
+			t.Error("Expected a nil cache to cache nothing")
+		}
+		value, hit, err := c.Load("a", func() (int, error) { return 2, nil })
+		if value != 2 || hit || err != nil {
+			t.Errorf("Expected a nil cache to load every time, got %d %v %v", value, hit, err)
+		}
+		if c.Len() != 0 || c.Purge() != 0 {
+			t.Error("Expected a nil cache to be empty")
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c, clk := newTestCache(10, time.Minute)
+	c.Set("a", 1)
+
+	clk.now = clk.now.Add(59 * time.Second)
+	if value, ok := c.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected a fresh entry, got %d %v", value, ok)
+	}
+
+	// Reading an entry does not extend its lifetime.
+	clk.now = clk.now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected the entry to expire after its TTL")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Expected the expired entry to be dropped, got %d entries", c.Len())
+	}
+
+	// Setting an entry again renews it.
+	c.Set("b", 1)
+	clk.now = clk.now.Add(30 * time.Second)
+	c.Set("b", 2)
+	clk.now = clk.now.Add(45 * time.Second)
+	if value, ok := c.Get("b"); !ok || value != 2 {
+		t.Errorf("Expected the renewed entry, got %d %v", value, ok)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c, _ := newTestCache(3, time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	// Touching a makes b the least recently used.
+	c.Get("a")
+	c.Set("d", 4)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Expected %s to be cached", key)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Expected 3 entries, got %d", c.Len())
+	}
+
+	// Overwriting an entry counts as a use and does not grow the cache.
+	c.Set("a", 10)
+	c.Set("e", 5)
+	if _, ok := c.Get("c"); ok {
+		t.Error("Expected c to be evicted")
+	}
+	if value, ok := c.Get("a"); !ok || value != 10 {
+		t.Errorf("Expected a to be 10, got %d %v", value, ok)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	calls := 0
+	load := func() (int, error) {
+		calls++
+		return 42, nil
+	}
+
+	if value, hit, err := c.Load("a", load); value != 42 || hit || err != nil {
+		t.Errorf("Expected a miss loading 42, got %d %v %v", value, hit, err)
+	}
+	if value, hit, err := c.Load("a", load); value != 42 || !hit || err != nil {
+		t.Errorf("Expected a hit for 42, got %d %v %v", value, hit, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected load to be called once, got %d", calls)
+	}
+
+	failure := errors.New("unavailable")
+	if _, _, err := c.Load("b", func() (int, error) { return 0, failure }); !errors.Is(err, failure) {
+		t.Errorf("Expected the load error, got %v", err)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected a failed load not to be cached")
+	}
+}
+
+func TestLoadGenerationGuard(t *testing.T) {
+	c, _ := newTestCache(10, time.Minute)
+	c.Set("other", 1)
+
+	// The cache is purged while the value is being loaded, so the loaded
+	// value may predate the change that caused the purge.
+	value, hit, err := c.Load("a", func() (int, error) {
+		if n := c.Purge(); n != 1 {
+			t.Errorf("Expected Purge to drop 1 entry, got %d", n)
+		}
+		return 1, nil
+	})
+	if value != 1 || hit || err != nil {
+		t.Errorf("Expected the loaded value to be returned, got %d %v %v", value, hit, err)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a value loaded during a purge not to be cached")
+	}
+
+	// Loads that start after the purge are cached again.
+	c.Load("a", func() (int, error) { return 2, nil })
+	if value, ok := c.Get("a"); !ok || value != 2 {
+		t.Errorf("Expected 2 to be cached, got %d %v", value, ok)
+	}
+}
@@ -0,0 +1,94 @@
+package cleanup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+const batchSize = 100
+
+// ExpireCarts removes carts that have not changed for longer than inactivity
+// and were never checked out. Each expired cart releases its products and
+// coupon and leaves a CartAbandonment record behind.
+func ExpireCarts(db *gorm.DB, inactivity time.Duration) (int, error) {
+	cutoff := time.Now().Add(-inactivity)
+	expired := 0
+
+	for {
+		var carts []models.Cart
+		err := db.Preload("Products").Preload("Items").
+			Where("updated_at < ?", cutoff).
+			Where("id NOT IN (?)", db.Model(&models.Order{}).Select("cart_id")).
+			Order("id").
+			Limit(batchSize).
+			Find(&carts).Error
+		if err != nil {
+			return expired, err
+		}
+
+		for i := range carts {
+			if err := expireCart(db, &carts[i]); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(carts) < batchSize {
+			return expired, nil
+		}
+	}
+}
+
+func expireCart(db *gorm.DB, cart *models.Cart) error {
+	units := cart.Units()
+	abandonment := models.CartAbandonment{
+		CartID:         cart.ID,
+		UserID:         cart.UserID,
+		ProductCount:   len(units),
+		CouponID:       cart.CouponID,
+		LastActivityAt: cart.UpdatedAt,
+	}
+	for _, product := range units {
+		abandonment.Subtotal += product.Price
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&abandonment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(cart).Association("Products").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(cart).UpdateColumn("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(cart).Error
+	})
+}
+
+// Start runs ExpireCarts every interval until ctx is cancelled.
+func Start(ctx context.Context, db *gorm.DB, interval, inactivity time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := ExpireCarts(db, inactivity)
+			if err != nil {
+				slog.Error("expiring carts", "error", err)
+				continue
+			}
+			if count > 0 {
+				slog.Info("expired abandoned carts", "count", count)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// joinTables lists the many2many tables whose rows point at a soft-deleted
+// row of a table and must go before the row itself.
+var joinTables = map[string][]joinColumn{
+	"products":   {{"cart_products", "product_id"}, {"promotion_products", "product_id"}},
+	"carts":      {{"cart_products", "cart_id"}},
+	"categories": {{"coupon_categories", "category_id"}},
+	"coupons":    {{"coupon_categories", "coupon_id"}},
+	"promotions": {{"promotion_products", "promotion_id"}},
+	"reviews":    {{"review_votes", "review_id"}},
+}
+
+type joinColumn struct {
+	table, column string
+}
+
+// PurgeDeleted permanently removes rows of models that were soft deleted
+// before cutoff; models without soft deletes are skipped. models are purged in reverse order, so listing parents
+// before children (as migrations.Models does) removes children first. A
+// table that cannot be purged, e.g. because live rows still reference its
+// deleted rows, is reported and skipped. It returns the number of rows
+// removed per table.
+func PurgeDeleted(db *gorm.DB, cutoff time.Time, models ...interface{}) (map[string]int64, error) {
+	purged := map[string]int64{}
+	var errs []error
+
+	for i := len(models) - 1; i >= 0; i-- {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(models[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if stmt.Schema.LookUpField("DeletedAt") == nil {
+			continue
+		}
+		table := stmt.Schema.Table
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			deleted := tx.Unscoped().Model(models[i]).Select("id").
+				Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+
+			for _, join := range joinTables[table] {
+				err := tx.Exec("DELETE FROM "+join.table+" WHERE "+join.column+" IN (?)", deleted).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			result := tx.Unscoped().
+				Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+				Delete(models[i])
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				purged[table] = result.RowsAffected
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", table, err))
+		}
+	}
+
+	return purged, errors.Join(errs...)
+}
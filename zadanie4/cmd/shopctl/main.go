@@ -0,0 +1,264 @@
+// Command shopctl runs maintenance tasks against the shop database: schema
+// migrations, seeding, product import and export, admin accounts, purging
+// soft-deleted rows and cart and order statistics. It reads the same
+// DB_* and DEFAULT_STORE environment variables as the server.
+//
+//	shopctl [-store slug] <command> [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"shop/auth"
+	"shop/cleanup"
+	"shop/config"
+	"shop/logging"
+	"shop/migrations"
+	"shop/models"
+	"shop/recommendations"
+	"shop/tenant"
+
+	"gorm.io/gorm"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"migrate", "create or upgrade the schema, the default store and VAT classes", runMigrate},
+		{"seed", "add default categories, shipping methods and VAT classes and, with -products, sample products", runSeed},
+		{"import-products", "create or update products from a JSON or CSV file", runImportProducts},
+		{"export-products", "write the store's products as JSON or CSV", runExportProducts},
+		{"create-admin", "create an admin user of the store", runCreateAdmin},
+		{"purge-deleted", "permanently remove soft-deleted rows", runPurgeDeleted},
+		{"stats", "print cart and order statistics", runStats},
+		{"recommendations", "recompute frequently bought together products", runRecommendations},
+	}
+}
+
+// storeSlug is the store that store-scoped commands work on.
+var storeSlug string
+
+func main() {
+	flag.StringVar(&storeSlug, "store", config.StringEnv("DEFAULT_STORE", tenant.DefaultSlug), "slug of the store to work on")
+	flag.Usage = usage
+	flag.Parse()
+
+	// Logs go to stderr, so exports can be redirected from stdout.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel()})))
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(flag.Args()[1:]); err != nil {
+				logging.Fatal(name+" failed", "error", err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "shopctl: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: shopctl [-store slug] <command> [flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nRun 'shopctl <command> -h' for the flags of a command.")
+}
+
+func logLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.StringEnv("LOG_LEVEL", "warn"))); err != nil {
+		return slog.LevelWarn
+	}
+	return level
+}
+
+// connect opens the database like the server does, with store isolation.
+// Commands call it after parsing their flags, so -h works offline.
+func connect() {
+	if config.DB != nil {
+		return
+	}
+	config.ConnectDB()
+	if err := tenant.Register(config.DB); err != nil {
+		logging.Fatal("Nie można zarejestrować izolacji sklepów", "error", err)
+	}
+}
+
+// storeDB returns the connection limited to the store selected by -store.
+func storeDB() (*gorm.DB, error) {
+	connect()
+	var store models.Store
+	err := config.DB.WithContext(tenant.AllStores(context.Background())).
+		Where("slug = ?", storeSlug).First(&store).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("store %q does not exist, run migrate first", storeSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.DB.WithContext(tenant.WithStore(context.Background(), store)), nil
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: shopctl %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runMigrate(args []string) error {
+	if err := newFlagSet("migrate", "").Parse(args); err != nil {
+		return err
+	}
+	connect()
+
+	store, err := migrations.Run(config.DB, config.StringEnv("DEFAULT_STORE", tenant.DefaultSlug))
+	if err != nil {
+		return err
+	}
+	db := config.DB.WithContext(tenant.WithStore(context.Background(), store))
+	if err := migrations.SeedTaxClasses(db, taxRegion()); err != nil {
+		return err
+	}
+	fmt.Printf("schema up to date, default store %q (id %d)\n", store.Slug, store.ID)
+	return nil
+}
+
+// taxRegion is the region VAT classes are seeded for, like the server's.
+func taxRegion() string {
+	return strings.ToUpper(config.StringEnv("TAX_REGION", "PL"))
+}
+
+func runSeed(args []string) error {
+	fs := newFlagSet("seed", "[-products]")
+	withProducts := fs.Bool("products", false, "also add sample products")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := storeDB()
+	if err != nil {
+		return err
+	}
+	if err := migrations.SeedCategories(db); err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d categories\n", len(migrations.DefaultCategories))
+	if err := migrations.SeedShippingMethods(db); err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d shipping methods\n", len(migrations.DefaultShippingMethods))
+	if err := migrations.SeedTaxClasses(db, taxRegion()); err != nil {
+		return err
+	}
+	fmt.Println("seeded VAT classes")
+
+	if *withProducts {
+		created, err := migrations.SeedProducts(db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("seeded %d products\n", created)
+	}
+	return nil
+}
+
+func runCreateAdmin(args []string) error {
+	fs := newFlagSet("create-admin", "-email address [-password secret]")
+	email := fs.String("email", "", "email of the admin")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password of the admin (default $ADMIN_PASSWORD)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *password == "" {
+		fs.Usage()
+		return errors.New("-email and -password are required")
+	}
+
+	db, err := storeDB()
+	if err != nil {
+		return err
+	}
+	created, err := auth.EnsureAdmin(db, *email, *password)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("a user with email %s already exists", *email)
+	}
+	fmt.Printf("created admin %s\n", *email)
+	return nil
+}
+
+func runPurgeDeleted(args []string) error {
+	fs := newFlagSet("purge-deleted", "[-older-than 720h]")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "only purge rows deleted at least this long ago")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	connect()
+	db := config.DB.WithContext(tenant.AllStores(context.Background()))
+	purged, err := cleanup.PurgeDeleted(db, time.Now().Add(-*olderThan), migrations.Models...)
+	tables := make([]string, 0, len(purged))
+	for table := range purged {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		fmt.Printf("%-26s %d\n", table, purged[table])
+	}
+	if len(purged) == 0 && err == nil {
+		fmt.Println("nothing to purge")
+	}
+	return err
+}
+
+func runRecommendations(args []string) error {
+	fs := newFlagSet("recommendations", "[-min-support 2] [-per-product 20]")
+	minSupport := fs.Int("min-support", config.IntEnv("RECOMMENDATIONS_MIN_SUPPORT", 2), "carts two products must share to be related")
+	perProduct := fs.Int("per-product", config.IntEnv("RECOMMENDATIONS_PER_PRODUCT", 20), "related products kept per product")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	connect()
+	db := config.DB.WithContext(tenant.AllStores(context.Background()))
+	count, err := recommendations.Compute(db, *minSupport, *perProduct)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("stored %d product associations\n", count)
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"shop/models"
+	"shop/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// productRecord is one product in an import or export file. Products are
+// matched by name within the store and categories by name.
+type productRecord struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+}
+
+var csvHeader = []string{"name", "description", "price", "category"}
+
+// format picks json or csv from the -format flag or the file extension.
+func format(flagValue, path string) (string, error) {
+	if flagValue == "" {
+		flagValue = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch flagValue {
+	case "json", "csv":
+		return flagValue, nil
+	case "":
+		return "json", nil
+	default:
+		return "", fmt.Errorf("unsupported format %q, use json or csv", flagValue)
+	}
+}
+
+func runImportProducts(args []string) error {
+	fs := newFlagSet("import-products", "[-format json|csv] file")
+	formatFlag := fs.String("format", "", "file format, json or csv (default from the file extension)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one file, or - for stdin")
+	}
+
+	path := fs.Arg(0)
+	f, err := format(*formatFlag, path)
+	if err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if path != "-" {
+		if in, err = os.Open(path); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	records, err := readProducts(in, f)
+	if err != nil {
+		return err
+	}
+
+	db, err := storeDB()
+	if err != nil {
+		return err
+	}
+
+	var created, updated int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		categories := map[string]uint{}
+		for i, record := range records {
+			if record.Name == "" {
+				return fmt.Errorf("product %d: name is required", i+1)
+			}
+
+			categoryID, ok := categories[record.Category]
+			if !ok && record.Category != "" {
+				category := models.Category{Name: record.Category}
+				if err := tx.Where(models.Category{Name: record.Category}).FirstOrCreate(&category).Error; err != nil {
+					return err
+				}
+				categoryID = category.ID
+				categories[record.Category] = categoryID
+			}
+
+			var product models.Product
+			err := tx.Where("name = ?", record.Name).First(&product).Error
+			isNew := errors.Is(err, gorm.ErrRecordNotFound)
+			if err != nil && !isNew {
+				return err
+			}
+
+			product.Name = record.Name
+			product.Description = record.Description
+			product.Price = record.Price
+			product.CategoryID = categoryID
+
+			event := webhooks.ProductUpdated
+			if isNew {
+				event = webhooks.ProductCreated
+				err = tx.Create(&product).Error
+				created++
+			} else {
+				err = tx.Save(&product).Error
+				updated++
+			}
+			if err != nil {
+				return fmt.Errorf("product %q: %w", record.Name, err)
+			}
+			if err := webhooks.Enqueue(tx, event, product); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d products into store %q: %d created, %d updated\n", len(records), storeSlug, created, updated)
+	return nil
+}
+
+func readProducts(r io.Reader, format string) ([]productRecord, error) {
+	if format == "json" {
+		var records []productRecord
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("decoding products: %w", err)
+		}
+		return records, nil
+	}
+
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading products: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %q column, expected header %s", name, strings.Join(csvHeader, ","))
+		}
+	}
+
+	records := make([]productRecord, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		price, err := strconv.ParseFloat(strings.TrimSpace(row[columns["price"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price %q", line+2, row[columns["price"]])
+		}
+		records = append(records, productRecord{
+			Name:        strings.TrimSpace(row[columns["name"]]),
+			Description: row[columns["description"]],
+			Price:       price,
+			Category:    strings.TrimSpace(row[columns["category"]]),
+		})
+	}
+	return records, nil
+}
+
+func runExportProducts(args []string) error {
+	fs := newFlagSet("export-products", "[-format json|csv] [-o file]")
+	formatFlag := fs.String("format", "", "file format, json or csv (default from -o, else json)")
+	output := fs.String("o", "-", "file to write, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "-" {
+		path = ""
+	}
+	f, err := format(*formatFlag, path)
+	if err != nil {
+		return err
+	}
+
+	db, err := storeDB()
+	if err != nil {
+		return err
+	}
+
+	var products []models.Product
+	if err := db.Preload("Category").Order("id").Find(&products).Error; err != nil {
+		return err
+	}
+
+	records := make([]productRecord, len(products))
+	for i, product := range products {
+		records[i] = productRecord{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+			Category:    product.Category.Name,
+		}
+	}
+
+	if path == "" {
+		return writeProducts(os.Stdout, f, records)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeProducts(out, f, records); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func writeProducts(w io.Writer, format string, records []productRecord) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, record := range records {
+		row := []string{
+			record.Name,
+			record.Description,
+			strconv.FormatFloat(record.Price, 'f', 2, 64),
+			record.Category,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+// stats summarises the carts and orders of one store.
+type stats struct {
+	Store             string  `json:"store"`
+	Carts             int64   `json:"carts"`
+	OpenCarts         int64   `json:"open_carts"`
+	CartsWithCoupon   int64   `json:"carts_with_coupon"`
+	AbandonedCarts    int64   `json:"abandoned_carts"`
+	AbandonedSubtotal float64 `json:"abandoned_subtotal"`
+	Orders            int64   `json:"orders"`
+	OrdersSince       int64   `json:"orders_since"`
+	Revenue           float64 `json:"revenue"`
+	Discounts         float64 `json:"discounts"`
+	AverageOrder      float64 `json:"average_order"`
+	Since             string  `json:"since"`
+}
+
+func runStats(args []string) error {
+	fs := newFlagSet("stats", "[-since 24h] [-json]")
+	since := fs.Duration("since", 24*time.Hour, "window for recent orders")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := storeDB()
+	if err != nil {
+		return err
+	}
+
+	s, err := collectStats(db, time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+	s.Store = storeSlug
+	s.Since = since.String()
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+
+	fmt.Printf("store               %s\n", s.Store)
+	fmt.Printf("carts               %d\n", s.Carts)
+	fmt.Printf("  open              %d\n", s.OpenCarts)
+	fmt.Printf("  with coupon       %d\n", s.CartsWithCoupon)
+	fmt.Printf("abandoned carts     %d (%.2f)\n", s.AbandonedCarts, s.AbandonedSubtotal)
+	fmt.Printf("orders              %d\n", s.Orders)
+	fmt.Printf("  last %-12s %d\n", s.Since, s.OrdersSince)
+	fmt.Printf("revenue             %.2f\n", s.Revenue)
+	fmt.Printf("discounts           %.2f\n", s.Discounts)
+	fmt.Printf("average order       %.2f\n", s.AverageOrder)
+	return nil
+}
+
+func collectStats(db *gorm.DB, since time.Time) (stats, error) {
+	var s stats
+	ordered := db.Model(&models.Order{}).Select("cart_id")
+
+	if err := db.Model(&models.Cart{}).Count(&s.Carts).Error; err != nil {
+		return s, err
+	}
+	if err := db.Model(&models.Cart{}).Where("id NOT IN (?)", ordered).Count(&s.OpenCarts).Error; err != nil {
+		return s, err
+	}
+	if err := db.Model(&models.Cart{}).Where("coupon_id IS NOT NULL").Count(&s.CartsWithCoupon).Error; err != nil {
+		return s, err
+	}
+
+	// Abandonments have no store of their own; expired carts are soft
+	// deleted, so they are found through the store's carts.
+	var abandoned struct {
+		Count    int64
+		Subtotal float64
+	}
+	err := db.Model(&models.CartAbandonment{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(subtotal), 0) AS subtotal").
+		Where("cart_id IN (?)", db.Unscoped().Model(&models.Cart{}).Select("id")).
+		Scan(&abandoned).Error
+	if err != nil {
+		return s, err
+	}
+	s.AbandonedCarts, s.AbandonedSubtotal = abandoned.Count, abandoned.Subtotal
+
+	var orders struct {
+		Count     int64
+		Revenue   float64
+		Discounts float64
+	}
+	err = db.Model(&models.Order{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(total), 0) AS revenue, COALESCE(SUM(discount + promotion_discount), 0) AS discounts").
+		Scan(&orders).Error
+	if err != nil {
+		return s, err
+	}
+	s.Orders, s.Revenue, s.Discounts = orders.Count, orders.Revenue, orders.Discounts
+	if s.Orders > 0 {
+		s.AverageOrder = s.Revenue / float64(s.Orders)
+	}
+
+	if err := db.Model(&models.Order{}).Where("created_at >= ?", since).Count(&s.OrdersSince).Error; err != nil {
+		return s, err
+	}
+	return s, nil
+}
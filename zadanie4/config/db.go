@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"time"
+
+	"shop/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -22,9 +24,11 @@ func ConnectDB() {
 		dbHost, dbUser, dbPassword, dbName,
 	)
 
-	connection, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	connection, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logging.NewGormLogger(DurationEnv("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond)),
+	})
 	if err != nil {
-		log.Fatal("Nieudane połączenie z bazą danych: ", err)
+		logging.Fatal("Nieudane połączenie z bazą danych", "error", err)
 	}
 
 	DB = connection
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DurationEnv reads a time.Duration such as "72h" from the environment,
+// falling back when the variable is unset or invalid.
+func DurationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		slog.Warn("invalid duration in environment", "name", name, "value", value, "fallback", fallback)
+		return fallback
+	}
+
+	return duration
+}
+
+// IntEnv reads an integer from the environment, falling back when the
+// variable is unset or invalid.
+func IntEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("invalid integer in environment", "name", name, "value", value, "fallback", fallback)
+		return fallback
+	}
+
+	return n
+}
+
+// StringEnv reads a string from the environment with a fallback.
+func StringEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"shop/addresses"
+	"shop/auth"
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var errAddressNotFound = errors.New("address not found")
+
+// addressProblems maps problems found by addresses.Validate to error codes.
+var addressProblems = map[string]i18n.Code{
+	addresses.ProblemRequired:   i18n.CodeAddressIncomplete,
+	addresses.ProblemCountry:    i18n.CodeInvalidCountry,
+	addresses.ProblemPostalCode: i18n.CodeInvalidPostalCode,
+	addresses.ProblemPhone:      i18n.CodeInvalidPhone,
+}
+
+type addressRequest struct {
+	Label string `json:"label"`
+	models.PostalAddress
+	DefaultShipping bool `json:"default_shipping"`
+	DefaultBilling  bool `json:"default_billing"`
+}
+
+// currentUserID is the signed-in user of the request. API keys act for no
+// user, so they have no address book.
+func currentUserID(ctx context.Context) (uint, error) {
+	principal := auth.FromContext(ctx)
+	if principal == nil || principal.UserID == 0 {
+		return 0, auth.ErrUnauthenticated
+	}
+	return principal.UserID, nil
+}
+
+// bindAddress validates the request body and copies it onto address.
+func bindAddress(c echo.Context, address *models.Address) i18n.Code {
+	req := new(addressRequest)
+	if err := c.Bind(req); err != nil {
+		return i18n.CodeInvalidRequest
+	}
+
+	addresses.Normalize(&req.PostalAddress)
+	if problem := addresses.Validate(req.PostalAddress); problem != "" {
+		return addressProblems[problem]
+	}
+
+	address.Label = strings.TrimSpace(req.Label)
+	address.PostalAddress = req.PostalAddress
+	address.DefaultShipping = req.DefaultShipping
+	address.DefaultBilling = req.DefaultBilling
+	return ""
+}
+
+// findAddress loads an address of the user.
+func findAddress(ctx context.Context, userID uint, id interface{}) (models.Address, error) {
+	var address models.Address
+	err := requestDB(ctx).Where("user_id = ?", userID).First(&address, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return address, errAddressNotFound
+	}
+	return address, err
+}
+
+// saveAddress stores address and takes the default flags it sets away from
+// the user's other addresses. A user's first address is the default for
+// both shipping and billing.
+func saveAddress(tx *gorm.DB, address *models.Address) error {
+	var others int64
+	if err := tx.Model(&models.Address{}).Where("user_id = ? AND id <> ?", address.UserID, address.ID).Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		address.DefaultShipping, address.DefaultBilling = true, true
+	}
+
+	if err := tx.Save(address).Error; err != nil {
+		return err
+	}
+
+	for column, isDefault := range map[string]bool{"default_shipping": address.DefaultShipping, "default_billing": address.DefaultBilling} {
+		if !isDefault {
+			continue
+		}
+		err := tx.Model(&models.Address{}).
+			Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+			UpdateColumn(column, false).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := promoteDefaults(tx, address.UserID); err != nil {
+		return err
+	}
+	return tx.First(address, address.ID).Error
+}
+
+// promoteDefaults makes the user's oldest address the default for shipping
+// or billing when no address is, e.g. after the default one was deleted.
+func promoteDefaults(tx *gorm.DB, userID uint) error {
+	for _, column := range []string{"default_shipping", "default_billing"} {
+		var defaults int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ? AND "+column+" = ?", userID, true).Count(&defaults).Error; err != nil {
+			return err
+		}
+		if defaults > 0 {
+			continue
+		}
+
+		var oldest models.Address
+		if err := tx.Where("user_id = ?", userID).Order("id").Limit(1).Find(&oldest).Error; err != nil {
+			return err
+		}
+		if oldest.ID == 0 {
+			return nil
+		}
+		if err := tx.Model(&oldest).UpdateColumn(column, true).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAddresses lists the address book of the signed-in user, defaults first.
+func GetAddresses(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	list := []models.Address{}
+	err = requestDB(ctx).Where("user_id = ?", userID).
+		Order("default_shipping DESC, default_billing DESC, id").
+		Find(&list).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+func GetAddressByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	address, err := findAddress(ctx, userID, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, address)
+}
+
+func CreateAddress(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	address := models.Address{UserID: userID}
+	if code := bindAddress(c, &address); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	if err := requestDB(ctx).Transaction(func(tx *gorm.DB) error { return saveAddress(tx, &address) }); err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddress replaces an address. Orders placed with it keep their copy.
+func UpdateAddress(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	address, err := findAddress(ctx, userID, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	if code := bindAddress(c, &address); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	if err := requestDB(ctx).Transaction(func(tx *gorm.DB) error { return saveAddress(tx, &address) }); err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, address)
+}
+
+func DeleteAddress(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	address, err := findAddress(ctx, userID, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		return promoteDefaults(tx, userID)
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Address deleted"})
+}
+
+// orderAddresses returns copies of the shipping and billing addresses of an
+// order for the cart's user: the addresses named at checkout, else the
+// user's defaults. Billing falls back to the shipping address. Guest carts
+// have no address book, so their orders get none.
+func orderAddresses(ctx context.Context, userID uint, req checkoutRequest) (shipping, billing models.PostalAddress, err error) {
+	if userID == 0 {
+		if req.ShippingAddressID != nil || req.BillingAddressID != nil {
+			return shipping, billing, errAddressNotFound
+		}
+		return shipping, billing, nil
+	}
+
+	pick := func(id *uint, defaultColumn string) (models.PostalAddress, error) {
+		if id != nil {
+			address, err := findAddress(ctx, userID, *id)
+			return address.PostalAddress, err
+		}
+		var address models.Address
+		err := requestDB(ctx).Where("user_id = ? AND "+defaultColumn+" = ?", userID, true).
+			Limit(1).Find(&address).Error
+		return address.PostalAddress, err
+	}
+
+	if shipping, err = pick(req.ShippingAddressID, "default_shipping"); err != nil {
+		return shipping, billing, err
+	}
+	if billing, err = pick(req.BillingAddressID, "default_billing"); err != nil {
+		return shipping, billing, err
+	}
+	if billing == (models.PostalAddress{}) {
+		billing = shipping
+	}
+	return shipping, billing, nil
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type apiKeyScopes struct {
+	Scopes []auth.Permission `json:"scopes"`
+}
+
+// validateAPIKey checks the request body. A key may only be given scopes
+// the caller has itself, so managing keys does not grant every permission.
+func validateAPIKey(ctx context.Context, req *apiKeyRequest) (int, i18n.Code) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return http.StatusBadRequest, i18n.CodeNameRequired
+	}
+	if len(req.Scopes) == 0 {
+		return http.StatusBadRequest, i18n.CodeInvalidScopes
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidPermission(scope) {
+			return http.StatusBadRequest, i18n.CodeInvalidScopes
+		}
+	}
+	principal := auth.FromContext(ctx)
+	for _, scope := range req.Scopes {
+		if !principal.Can(auth.Permission(scope)) {
+			return http.StatusForbidden, i18n.CodeScopeNotHeld
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return http.StatusBadRequest, i18n.CodeInvalidExpiry
+	}
+	return 0, ""
+}
+
+// CreateAPIKey issues a key for an integration. The key itself is only in
+// this response; afterwards listings show its prefix.
+func CreateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(apiKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if status, code := validateAPIKey(ctx, req); code != "" {
+		return errorJSON(c, status, code)
+	}
+
+	key, hash, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	apiKey := models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if principal := auth.FromContext(ctx); principal != nil {
+		apiKey.CreatedByID = principal.UserID
+	}
+	if err := requestDB(ctx).Create(&apiKey).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	apiKey.Key = key
+	return c.JSON(http.StatusCreated, apiKey)
+}
+
+// GetAPIKeys lists keys, revoked ones included, unless ?active=true.
+func GetAPIKeys(c echo.Context) error {
+	ctx := c.Request().Context()
+	keys := []models.APIKey{}
+	query := requestDB(ctx).Order("id")
+	if c.QueryParam("active") == "true" {
+		query = query.Where("revoked_at IS NULL").Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
+	if err := query.Find(&keys).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+func GetAPIKeyScopes(c echo.Context) error {
+	return c.JSON(http.StatusOK, apiKeyScopes{Scopes: auth.Permissions})
+}
+
+// UpdateAPIKey renames a key or changes its scopes and expiry. The key
+// value stays the same.
+func UpdateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	var apiKey models.APIKey
+	if err := requestDB(ctx).First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeAPIKeyNotFound)
+	}
+	if apiKey.RevokedAt != nil {
+		return errorJSON(c, http.StatusConflict, i18n.CodeAPIKeyRevoked)
+	}
+
+	req := new(apiKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if status, code := validateAPIKey(ctx, req); code != "" {
+		return errorJSON(c, status, code)
+	}
+
+	apiKey.Name = req.Name
+	apiKey.Scopes = req.Scopes
+	apiKey.ExpiresAt = req.ExpiresAt
+	if err := requestDB(ctx).Save(&apiKey).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, apiKey)
+}
+
+// RotateAPIKey replaces the key value, keeping name and scopes. The old
+// value stops working immediately, so the integration has to be updated
+// with the key from this response.
+func RotateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	var apiKey models.APIKey
+	if err := requestDB(ctx).First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeAPIKeyNotFound)
+	}
+	if apiKey.RevokedAt != nil {
+		return errorJSON(c, http.StatusConflict, i18n.CodeAPIKeyRevoked)
+	}
+
+	key, hash, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	apiKey.KeyHash = hash
+	apiKey.Prefix = prefix
+	apiKey.LastUsedAt = nil
+	if err := requestDB(ctx).Save(&apiKey).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	apiKey.Key = key
+	return c.JSON(http.StatusOK, apiKey)
+}
+
+// RevokeAPIKey disables a key for good. The row is kept so the listing
+// still shows who had access and when it was last used.
+func RevokeAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	var apiKey models.APIKey
+	if err := requestDB(ctx).First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeAPIKeyNotFound)
+	}
+
+	if apiKey.RevokedAt == nil {
+		now := time.Now()
+		apiKey.RevokedAt = &now
+		if err := requestDB(ctx).Model(&apiKey).Update("revoked_at", now).Error; err != nil {
+			return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, apiKey)
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"shop/auth"
+	"shop/config"
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const minPasswordLength = 8
+
+type registerRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token     string      `json:"token"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	User      models.User `json:"user"`
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Register creates a customer account. Staff and admin roles are only
+// granted by an admin through UpdateUserRole.
+func Register(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(registerRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	req.Email = normalizeEmail(req.Email)
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidEmail)
+	}
+	if len(req.Password) < minPasswordLength {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodePasswordTooShort)
+	}
+
+	var count int64
+	if err := requestDB(ctx).Model(&models.User{}).Where("email = ?", req.Email).Count(&count).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeEmailTaken)
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	user := models.User{Email: req.Email, Name: req.Name, Role: models.RoleCustomer, PasswordHash: hash}
+	if err := requestDB(ctx).Create(&user).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+// Login exchanges an email and password for a bearer token valid for
+// SESSION_TTL.
+func Login(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(loginRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	var user models.User
+	err := requestDB(ctx).Where("email = ?", normalizeEmail(req.Email)).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if err != nil || !auth.CheckPassword(user.PasswordHash, req.Password) {
+		return errorJSON(c, http.StatusUnauthorized, i18n.CodeInvalidCredentials)
+	}
+
+	token, hash, err := auth.NewToken("sess_")
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	session := models.Session{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(config.DurationEnv("SESSION_TTL", 24*time.Hour)),
+	}
+	if err := requestDB(ctx).Create(&session).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, loginResponse{Token: token, ExpiresAt: session.ExpiresAt, User: user})
+}
+
+// Logout revokes the bearer token the request was made with.
+func Logout(c echo.Context) error {
+	ctx := c.Request().Context()
+	if auth.FromContext(ctx) == nil {
+		return errorJSON(c, http.StatusUnauthorized, i18n.CodeUnauthenticated)
+	}
+
+	token := auth.BearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if err := requestDB(ctx).Where("token_hash = ?", auth.HashToken(token)).Delete(&models.Session{}).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Me returns the account of the authenticated caller.
+func Me(c echo.Context) error {
+	ctx := c.Request().Context()
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return errorJSON(c, http.StatusUnauthorized, i18n.CodeUnauthenticated)
+	}
+
+	var user models.User
+	if err := requestDB(ctx).First(&user, principal.UserID).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeUserNotFound)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// GetUsers lists accounts, optionally filtered by ?role.
+func GetUsers(c echo.Context) error {
+	ctx := c.Request().Context()
+	users := []models.User{}
+	query := requestDB(ctx).Order("id")
+	if role := c.QueryParam("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, users)
+}
+
+// UpdateUserRole assigns a role. Sessions keep working and pick the new
+// role up on their next request.
+func UpdateUserRole(c echo.Context) error {
+	ctx := c.Request().Context()
+	var user models.User
+	if err := requestDB(ctx).First(&user, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeUserNotFound)
+	}
+
+	req := new(roleRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if !models.IsValidRole(req.Role) {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidRole)
+	}
+
+	if err := requestDB(ctx).Model(&user).Update("role", req.Role).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
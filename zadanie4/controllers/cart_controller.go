@@ -1,75 +1,420 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strings"
+	"time"
 
+	"shop/auth"
 	"shop/config"
+	"shop/i18n"
+	"shop/metrics"
 	"shop/models"
+	"shop/promotions"
+	"shop/shipping"
+	"shop/tax"
+	"shop/webhooks"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+type createCartRequest struct {
+	Region string `json:"region"`
+}
+
+// CreateCart starts a cart for the signed-in caller, or a guest cart for
+// anonymous callers.
 func CreateCart(c echo.Context) error {
-	cart := new(models.Cart)
-	if err := c.Bind(cart); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+	ctx := c.Request().Context()
+	req := new(createCartRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	cart := &models.Cart{UserID: callerUserID(ctx), Region: strings.ToUpper(strings.TrimSpace(req.Region))}
+	if cart.Region == "" {
+		cart.Region = requestRegion(c)
+	} else if !regionPattern.MatchString(cart.Region) {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidRegion)
 	}
 
-	if err := config.DB.Create(cart).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	if err := createCart(ctx, cart); err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
 	}
 
 	return c.JSON(http.StatusCreated, cart)
 }
 
+// callerUserID is the ID of the signed-in user, or 0 for guests and API
+// keys.
+func callerUserID(ctx context.Context) uint {
+	if principal := auth.FromContext(ctx); principal != nil {
+		return principal.UserID
+	}
+	return 0
+}
+
+// canAccessCart reports whether the caller may read or change the cart.
+// Guest carts are open to whoever holds their ID; a user's cart only to
+// that user and order managers.
+func canAccessCart(ctx context.Context, cart models.Cart) bool {
+	return cart.UserID == 0 || canAccessOrders(ctx, cart.UserID)
+}
+
+func createCart(ctx context.Context, cart *models.Cart) error {
+	if cart.Region == "" {
+		cart.Region = taxConfig.Region
+	}
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cart).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.CartCreated, cart)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.CartsCreated.Inc()
+	return nil
+}
+
 func GetCartByID(c echo.Context) error {
+	ctx := c.Request().Context()
 	id := c.Param("id")
 	var cart models.Cart
 
-	if err := config.DB.Preload("Products").First(&cart, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Cart not found"})
+	if err := loadCart(ctx, &cart, id); err != nil {
+		return errorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusOK, cart)
 }
 
+// loadCart fetches a cart the caller may access with its products, their
+// quantities and the coupon and fills in totals.
+func loadCart(ctx context.Context, cart *models.Cart, id interface{}) error {
+	if err := requestDB(ctx).Preload("Products.Category").Preload("Items").Preload("Coupon.Categories").Preload("ShippingMethod.Rules").First(cart, id).Error; err != nil {
+		return errCartNotFound
+	}
+	if !canAccessCart(ctx, *cart) {
+		return errCartNotFound
+	}
+
+	return applyCartTotals(ctx, cart)
+}
+
+// applyCartTotals evaluates active promotions and the coupon against the cart
+// and sets its totals. A coupon that no longer applies to the cart contents
+// is shown with a zero discount, and a shipping method that no longer
+// applies with a zero cost. Discounts are spread over the tax lines and the
+// total is the gross amount after tax, shipping included; products need
+// their Category loaded for category tax classes to apply.
+func applyCartTotals(ctx context.Context, cart *models.Cart) error {
+	var active []models.Promotion
+	if err := requestDB(ctx).Preload("Products").Where("active = ?", true).Find(&active).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	units := cart.Units()
+	cart.Subtotal = 0
+	for _, product := range units {
+		cart.Subtotal += product.Price
+	}
+
+	cart.Promotions = promotions.Evaluate(active, units, now)
+	cart.PromotionDiscount = promotions.TotalDiscount(cart.Promotions)
+
+	cart.Discount = 0
+	if cart.Coupon != nil {
+		if discount, err := cart.Coupon.Discount(units, now); err == nil {
+			cart.Discount = math.Min(discount, cart.Subtotal-cart.PromotionDiscount)
+		}
+	}
+
+	if cart.Region == "" {
+		cart.Region = taxConfig.Region
+	}
+	cart.Weight = shipping.Weight(units)
+	cart.Shipping = 0
+	if cart.ShippingMethod != nil {
+		if quote, ok := shipping.Quote(*cart.ShippingMethod, units, cartValue(*cart), cart.Region); ok {
+			cart.Shipping = quote.Price
+		}
+	}
+
+	table, err := loadTaxTable(ctx)
+	if err != nil {
+		return err
+	}
+	cart.TaxLines = table.Lines(units, cart.Region, cart.PromotionDiscount+cart.Discount)
+	if cart.Shipping > 0 {
+		cart.TaxLines = append(cart.TaxLines, table.ShippingLine(cart.Shipping, cart.Region))
+	}
+	cart.Net, cart.Tax, cart.Total = tax.Totals(cart.TaxLines)
+	return nil
+}
+
+// cartValue is what the cart's products cost after discounts, which
+// shipping rules are matched against.
+func cartValue(cart models.Cart) float64 {
+	return cart.Subtotal - cart.PromotionDiscount - cart.Discount
+}
+
+// cartProductEvent is the payload of cart.product_added and
+// cart.product_removed webhooks. Quantity is how many units the cart holds
+// afterwards.
+type cartProductEvent struct {
+	CartID    uint    `json:"cart_id"`
+	ProductID uint    `json:"product_id"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+}
+
 func AddProductToCart(c echo.Context) error {
-	cartID := c.Param("cart_id")
-	productID := c.Param("product_id")
+	ctx := c.Request().Context()
+	cart, err := addProductToCart(ctx, c.Param("cart_id"), c.Param("product_id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
 
+	return c.JSON(http.StatusOK, cart)
+}
+
+// addProductToCart is the add-to-cart flow shared by the cart and wishlist
+// endpoints. Adding a product the cart already holds adds another unit.
+func addProductToCart(ctx context.Context, cartID, productID string) (*models.Cart, error) {
 	var cart models.Cart
-	if err := config.DB.First(&cart, cartID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Cart not found"})
+	if err := requestDB(ctx).First(&cart, cartID).Error; err != nil || !canAccessCart(ctx, cart) {
+		return nil, errCartNotFound
 	}
 
 	var product models.Product
-	if err := config.DB.First(&product, productID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Product not found"})
+	if err := requestDB(ctx).First(&product, productID).Error; err != nil {
+		return nil, errProductNotFound
 	}
 
-	if err := config.DB.Model(&cart).Association("Products").Append(&product); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		item := models.CartItem{CartID: cart.ID, ProductID: product.ID, Quantity: 1}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "cart_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("cart_products.quantity + 1")}),
+		}).Create(&item).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where(&models.CartItem{CartID: cart.ID, ProductID: product.ID}).First(&item).Error; err != nil {
+			return err
+		}
+		if err := touchCart(tx, &cart); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.CartProductAdded, cartProductEvent{cart.ID, product.ID, product.Price, item.Quantity})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return c.JSON(http.StatusOK, cart)
+	return &cart, nil
+}
+
+// touchCart marks the cart as active so the cleanup worker does not expire it.
+func touchCart(tx *gorm.DB, cart *models.Cart) error {
+	return tx.Model(cart).Update("updated_at", time.Now()).Error
 }
 
 func RemoveProductFromCart(c echo.Context) error {
-	cartID := c.Param("cart_id")
-	productID := c.Param("product_id")
+	ctx := c.Request().Context()
+	cart, err := removeProductFromCart(ctx, c.Param("cart_id"), c.Param("product_id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
 
+// removeProductFromCart takes a product out of the cart with all its units.
+func removeProductFromCart(ctx context.Context, cartID, productID string) (*models.Cart, error) {
 	var cart models.Cart
-	if err := config.DB.Preload("Products").First(&cart, cartID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Cart not found"})
+	if err := requestDB(ctx).Preload("Products").First(&cart, cartID).Error; err != nil || !canAccessCart(ctx, cart) {
+		return nil, errCartNotFound
 	}
 
 	var product models.Product
-	if err := config.DB.First(&product, productID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Product not found"})
+	if err := requestDB(ctx).First(&product, productID).Error; err != nil {
+		return nil, errProductNotFound
+	}
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&cart).Association("Products").Delete(&product); err != nil {
+			return err
+		}
+		if err := touchCart(tx, &cart); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.CartProductRemoved, cartProductEvent{cart.ID, product.ID, product.Price, 0})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+const (
+	MergeStrategySum    = "sum"
+	MergeStrategyNewest = "newest"
+)
+
+type mergeCartRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+// MergeGuestCart moves a guest cart (UserID 0) into the signed-in caller's
+// active cart and deletes the guest cart. The strategies decide what survives when both
+// carts have contents:
+//   - sum: both carts' products, with the quantities of products in both
+//     added up; the user's coupon wins if set
+//   - newest: the more recently updated cart's products, quantities and
+//     coupon win
+//
+// The default comes from CART_MERGE_STRATEGY and can be overridden per request.
+func MergeGuestCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
 	}
 
-	if err := config.DB.Model(&cart).Association("Products").Delete(&product); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	req := new(mergeCartRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if req.Strategy == "" {
+		req.Strategy = config.StringEnv("CART_MERGE_STRATEGY", MergeStrategySum)
+	}
+	if req.Strategy != MergeStrategySum && req.Strategy != MergeStrategyNewest {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidMergeStrategy)
+	}
+
+	var guest models.Cart
+	if err := requestDB(ctx).Preload("Items").First(&guest, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeCartNotFound)
+	}
+	if guest.UserID != 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeCartNotGuest)
+	}
+	checkedOut, err := isCheckedOut(ctx, guest.ID)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if checkedOut {
+		return errorResponse(c, errCartCheckedOut)
+	}
+
+	var userCart models.Cart
+	err = requestDB(ctx).Preload("Items").
+		Where("user_id = ?", userID).
+		Where("id NOT IN (?)", requestDB(ctx).Model(&models.Order{}).Select("cart_id")).
+		Order("updated_at DESC").
+		First(&userCart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Nothing to merge with, the guest cart simply becomes the user's cart.
+		if err := requestDB(ctx).Model(&guest).Update("user_id", userID).Error; err != nil {
+			return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+		}
+		return respondWithCart(c, guest.ID)
+	}
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := mergeCarts(tx, &userCart, &guest, req.Strategy); err != nil {
+			return err
+		}
+		if err := tx.Model(&userCart).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("cart_id = ?", guest.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&guest).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return respondWithCart(c, userCart.ID)
+}
+
+func mergeCarts(tx *gorm.DB, userCart, guest *models.Cart, strategy string) error {
+	switch strategy {
+	case MergeStrategyNewest:
+		if !guest.UpdatedAt.After(userCart.UpdatedAt) {
+			return nil
+		}
+		if err := tx.Where("cart_id = ?", userCart.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := copyCartItems(tx, userCart.ID, guest.Items, nil); err != nil {
+			return err
+		}
+		return tx.Model(userCart).UpdateColumn("coupon_id", guest.CouponID).Error
+	default:
+		if err := copyCartItems(tx, userCart.ID, guest.Items, userCart.Items); err != nil {
+			return err
+		}
+		if userCart.CouponID == nil && guest.CouponID != nil {
+			return tx.Model(userCart).UpdateColumn("coupon_id", guest.CouponID).Error
+		}
+		return nil
+	}
+}
+
+// copyCartItems puts items into the cart, adding their quantities to the
+// ones of the existing items of the same products.
+func copyCartItems(tx *gorm.DB, cartID uint, items, existing []models.CartItem) error {
+	held := make(map[uint]models.CartItem, len(existing))
+	for _, item := range existing {
+		held[item.ProductID] = item
+	}
+
+	for _, item := range items {
+		if current, ok := held[item.ProductID]; ok {
+			err := tx.Model(&models.CartItem{}).
+				Where("cart_id = ? AND product_id = ?", cartID, item.ProductID).
+				UpdateColumn("quantity", current.Quantity+item.Quantity).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Create(&models.CartItem{CartID: cartID, ProductID: item.ProductID, Quantity: item.Quantity}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isCheckedOut(ctx context.Context, cartID uint) (bool, error) {
+	var count int64
+	err := requestDB(ctx).Model(&models.Order{}).Where("cart_id = ?", cartID).Count(&count).Error
+	return count > 0, err
+}
+
+func respondWithCart(c echo.Context, id uint) error {
+	var cart models.Cart
+	if err := loadCart(c.Request().Context(), &cart, id); err != nil {
+		return errorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusOK, cart)
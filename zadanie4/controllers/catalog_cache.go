@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shop/cache"
+	"shop/metrics"
+	"shop/models"
+	"shop/tenant"
+
+	"github.com/labstack/echo/v4"
+)
+
+// The catalogue caches hold products with their category and rating
+// aggregates, keyed by store; feedCache in feed_controller.go holds the
+// feeds rendered from them. Cached values are shared between requests and
+// must not be modified. Both are nil, and so disabled, until
+// ConfigureCatalogCache runs.
+var (
+	productListCache *cache.Cache[uint, []models.Product]
+	productCache     *cache.Cache[productKey, models.Product]
+)
+
+type productKey struct {
+	StoreID   uint
+	ProductID uint
+}
+
+type cacheFlushResponse struct {
+	Flushed int `json:"flushed"`
+}
+
+// ConfigureCatalogCache enables the product caches with room for size
+// entries each, kept for at most ttl. A size of 0 turns caching off.
+func ConfigureCatalogCache(size int, ttl time.Duration) {
+	productListCache = cache.New[uint, []models.Product](size, ttl)
+	productCache = cache.New[productKey, models.Product](size, ttl)
+	feedCache = cache.New[feedKey, []byte](size, ttl)
+	recordCacheSize()
+}
+
+// invalidateCatalog drops every cached product. Catalogue writes are rare,
+// so dropping everything is simpler than working out which lists changed.
+func invalidateCatalog() int {
+	flushed := productListCache.Purge() + productCache.Purge() + feedCache.Purge()
+	recordCacheSize()
+	return flushed
+}
+
+// listProducts returns all products of the request's store with their
+// category and ratings.
+func listProducts(ctx context.Context) ([]models.Product, error) {
+	products, hit, err := productListCache.Load(tenant.ID(ctx), func() ([]models.Product, error) {
+		var products []models.Product
+		if err := requestDB(ctx).Preload("Category").Find(&products).Error; err != nil {
+			return nil, err
+		}
+		if err := attachRatings(ctx, products); err != nil {
+			return nil, err
+		}
+		return products, nil
+	})
+	recordCacheLookup("products", hit)
+	return products, err
+}
+
+// findProduct returns one product with its category and ratings, or
+// errProductNotFound.
+func findProduct(ctx context.Context, id uint) (models.Product, error) {
+	product, hit, err := productCache.Load(productKey{tenant.ID(ctx), id}, func() (models.Product, error) {
+		var product models.Product
+		if err := requestDB(ctx).Preload("Category").First(&product, id).Error; err != nil {
+			return product, errProductNotFound
+		}
+
+		products := []models.Product{product}
+		if err := attachRatings(ctx, products); err != nil {
+			return product, err
+		}
+		return products[0], nil
+	})
+	recordCacheLookup("product", hit)
+	return product, err
+}
+
+// parseProductID reads a product ID from a path parameter, treating
+// anything that is not a positive integer as an unknown product.
+func parseProductID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 0)
+	if err != nil || id == 0 {
+		return 0, errProductNotFound
+	}
+	return uint(id), nil
+}
+
+func recordCacheLookup(name string, hit bool) {
+	if hit {
+		metrics.CacheRequests.WithLabelValues(name, "hit").Inc()
+		return
+	}
+	metrics.CacheRequests.WithLabelValues(name, "miss").Inc()
+	recordCacheSize()
+}
+
+func recordCacheSize() {
+	metrics.CacheEntries.WithLabelValues("products").Set(float64(productListCache.Len()))
+	metrics.CacheEntries.WithLabelValues("product").Set(float64(productCache.Len()))
+	metrics.CacheEntries.WithLabelValues("feed").Set(float64(feedCache.Len()))
+}
+
+// FlushCache empties the catalogue caches, e.g. after editing products
+// directly in the database.
+func FlushCache(c echo.Context) error {
+	return c.JSON(http.StatusOK, cacheFlushResponse{Flushed: invalidateCatalog()})
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type couponRequest struct {
+	models.Coupon
+	CategoryIDs []uint `json:"category_ids"`
+}
+
+type applyCouponRequest struct {
+	Code string `json:"code"`
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validateCoupon(coupon *models.Coupon) i18n.Code {
+	if coupon.Code == "" {
+		return i18n.CodeCouponCodeRequired
+	}
+	switch coupon.Type {
+	case models.CouponTypePercentage:
+		if coupon.Value <= 0 || coupon.Value > 100 {
+			return i18n.CodeCouponInvalidValue
+		}
+	case models.CouponTypeFixed:
+		if coupon.Value <= 0 {
+			return i18n.CodeCouponInvalidValue
+		}
+	default:
+		return i18n.CodeCouponInvalidType
+	}
+	if coupon.ValidFrom != nil && coupon.ValidUntil != nil && coupon.ValidUntil.Before(*coupon.ValidFrom) {
+		return i18n.CodeInvalidPeriod
+	}
+	if coupon.MinCartValue < 0 || coupon.UsageLimit < 0 || coupon.UsageLimitPerUser < 0 {
+		return i18n.CodeCouponNegativeLimits
+	}
+	return ""
+}
+
+func CreateCoupon(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(couponRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	coupon := req.Coupon
+	coupon.Model = gorm.Model{}
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	coupon.Categories = nil
+	if code := validateCoupon(&coupon); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	if len(req.CategoryIDs) > 0 {
+		if err := requestDB(ctx).Find(&coupon.Categories, req.CategoryIDs).Error; err != nil {
+			return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+		}
+		if len(coupon.Categories) != len(req.CategoryIDs) {
+			return errorJSON(c, http.StatusBadRequest, i18n.CodeCategoryNotFound)
+		}
+	}
+
+	var count int64
+	if err := requestDB(ctx).Model(&models.Coupon{}).Where("code = ?", coupon.Code).Count(&count).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeCouponAlreadyExists)
+	}
+
+	if err := requestDB(ctx).Create(&coupon).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, coupon)
+}
+
+func GetCoupons(c echo.Context) error {
+	ctx := c.Request().Context()
+	coupons := []models.Coupon{}
+	if err := requestDB(ctx).Preload("Categories").Order("code").Find(&coupons).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, coupons)
+}
+
+func DeleteCoupon(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	var coupon models.Coupon
+
+	if err := requestDB(ctx).First(&coupon, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeCouponNotFound)
+	}
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Cart{}).Where("coupon_id = ?", coupon.ID).Update("coupon_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&coupon).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Coupon deleted"})
+}
+
+func ApplyCouponToCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(applyCouponRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	cart, err := applyCoupon(ctx, c.Param("id"), req.Code)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func RemoveCouponFromCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	cart, err := removeCoupon(ctx, c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func applyCoupon(ctx context.Context, cartID interface{}, code string) (*models.Cart, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, cartID); err != nil {
+		return nil, err
+	}
+
+	var coupon models.Coupon
+	err := requestDB(ctx).Preload("Categories").
+		Where("code = ?", normalizeCouponCode(code)).
+		First(&coupon).Error
+	if err != nil {
+		return nil, errCouponNotFound
+	}
+
+	if _, err := coupon.Discount(cart.Units(), time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkCouponUsage(requestDB(ctx), &coupon, cart.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := requestDB(ctx).Model(&cart).Update("coupon_id", coupon.ID).Error; err != nil {
+		return nil, err
+	}
+
+	cart.Coupon = &coupon
+	if err := applyCartTotals(ctx, &cart); err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+func removeCoupon(ctx context.Context, cartID interface{}) (*models.Cart, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, cartID); err != nil {
+		return nil, err
+	}
+
+	if err := requestDB(ctx).Model(&cart).Update("coupon_id", nil).Error; err != nil {
+		return nil, err
+	}
+
+	cart.CouponID = nil
+	cart.Coupon = nil
+	if err := applyCartTotals(ctx, &cart); err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+// checkCouponUsage enforces the global and per-user usage limits, counted
+// from redemptions recorded at checkout. Before recording one, callers lock
+// the coupon row in the same transaction, or concurrent checkouts could all
+// pass the check.
+func checkCouponUsage(db *gorm.DB, coupon *models.Coupon, userID uint) error {
+	var used, usedByUser int64
+	if coupon.UsageLimit > 0 {
+		if err := db.Model(&models.CouponRedemption{}).Where("coupon_id = ?", coupon.ID).Count(&used).Error; err != nil {
+			return err
+		}
+	}
+	if coupon.UsageLimitPerUser > 0 && userID != 0 {
+		err := db.Model(&models.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, userID).
+			Count(&usedByUser).Error
+		if err != nil {
+			return err
+		}
+	}
+	return coupon.CheckUsage(used, usedByUser, userID)
+}
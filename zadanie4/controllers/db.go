@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"context"
+
+	"shop/config"
+
+	"gorm.io/gorm"
+)
+
+// requestDB binds the shared connection to the request's context, so GORM
+// query logs carry its request ID.
+func requestDB(ctx context.Context) *gorm.DB {
+	return config.DB.WithContext(ctx)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+
+	"shop/auth"
+	"shop/models"
+	"shop/openapi"
+	"shop/recommendations"
+)
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// Operations documents every route registered by initRoutes. The OpenAPI
+// drift test in main_test.go fails when the two get out of sync.
+var Operations = []openapi.Operation{
+	{Method: http.MethodPost, Path: "/products", Tag: "products", Summary: "Create a product", Request: models.Product{}, Response: models.Product{}, Status: http.StatusCreated, Permission: string(auth.PermCatalogWrite)},
+	{Method: http.MethodGet, Path: "/products", Tag: "products", Summary: "List products with rating aggregates and taxed prices", Query: []string{"region", "prices"}, Response: []models.Product{}},
+	{Method: http.MethodGet, Path: "/products/:id", Tag: "products", Summary: "Get a product with rating aggregates and taxed prices", Query: []string{"region", "prices"}, Response: models.Product{}},
+	{Method: http.MethodPut, Path: "/products/:id", Tag: "products", Summary: "Update a product", Request: models.Product{}, Response: models.Product{}, Permission: string(auth.PermCatalogWrite)},
+	{Method: http.MethodDelete, Path: "/products/:id", Tag: "products", Summary: "Delete a product", Response: messageResponse{}, Permission: string(auth.PermCatalogWrite)},
+	{Method: http.MethodGet, Path: "/products/scopes", Tag: "products", Summary: "List products above a minimum price", Query: []string{"min_price", "region", "prices"}, Response: []models.Product{}},
+	{Method: http.MethodGet, Path: "/products/:id/recommendations", Tag: "products", Summary: "Products frequently bought together, then from the same category", Query: []string{"limit"}, Response: []recommendations.Recommendation{}},
+
+	{Method: http.MethodPost, Path: "/products/:id/reviews", Tag: "reviews", Summary: "Post a review for moderation as the signed-in user", Request: reviewRequest{}, Response: models.Review{}, Status: http.StatusCreated, Authenticated: true},
+	{Method: http.MethodGet, Path: "/products/:id/reviews", Tag: "reviews", Summary: "List approved reviews of a product", Query: []string{"page", "per_page", "sort"}, Response: reviewPage{}},
+	{Method: http.MethodGet, Path: "/reviews", Tag: "reviews", Summary: "List reviews by moderation status", Query: []string{"status", "page", "per_page", "sort"}, Response: reviewPage{}, Permission: string(auth.PermReviewsModerate)},
+	{Method: http.MethodPut, Path: "/reviews/:id", Tag: "reviews", Summary: "Edit a review; only its author or a moderator may", Request: reviewRequest{}, Response: models.Review{}, Authenticated: true},
+	{Method: http.MethodDelete, Path: "/reviews/:id", Tag: "reviews", Summary: "Delete a review; only its author or a moderator may", Response: messageResponse{}, Authenticated: true},
+	{Method: http.MethodPost, Path: "/reviews/:id/helpful", Tag: "reviews", Summary: "Mark a review as helpful, once per user", Response: models.Review{}, Authenticated: true},
+	{Method: http.MethodPut, Path: "/reviews/:id/moderation", Tag: "reviews", Summary: "Approve or reject a review", Request: moderationRequest{}, Response: models.Review{}, Permission: string(auth.PermReviewsModerate)},
+
+	{Method: http.MethodGet, Path: "/tax-classes", Tag: "tax", Summary: "List tax classes with their regional rates", Response: []models.TaxClass{}},
+	{Method: http.MethodPost, Path: "/tax-classes", Tag: "tax", Summary: "Create a tax class", Request: taxClassRequest{}, Response: models.TaxClass{}, Status: http.StatusCreated, Permission: string(auth.PermTaxManage)},
+	{Method: http.MethodPut, Path: "/tax-classes/:id", Tag: "tax", Summary: "Rename a tax class and replace its rates", Request: taxClassRequest{}, Response: models.TaxClass{}, Permission: string(auth.PermTaxManage)},
+	{Method: http.MethodPut, Path: "/categories/:id/tax-class", Tag: "tax", Summary: "Set the tax class of a category", Request: categoryTaxClassRequest{}, Response: models.Category{}, Permission: string(auth.PermCatalogWrite)},
+
+	{Method: http.MethodPost, Path: "/carts", Tag: "carts", Summary: "Create a cart, owned by the caller when signed in", Request: createCartRequest{}, Response: models.Cart{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/carts/:id", Tag: "carts", Summary: "Get a cart with totals", Response: models.Cart{}},
+	{Method: http.MethodPost, Path: "/carts/:cart_id/add-product/:product_id", Tag: "carts", Summary: "Add a unit of a product to a cart", Response: models.Cart{}},
+	{Method: http.MethodDelete, Path: "/carts/:cart_id/remove-product/:product_id", Tag: "carts", Summary: "Remove a product with all its units from a cart", Response: models.Cart{}},
+	{Method: http.MethodPost, Path: "/carts/:id/coupon", Tag: "carts", Summary: "Apply a coupon code", Request: applyCouponRequest{}, Response: models.Cart{}},
+	{Method: http.MethodDelete, Path: "/carts/:id/coupon", Tag: "carts", Summary: "Remove the coupon", Response: models.Cart{}},
+	{Method: http.MethodPost, Path: "/carts/:id/checkout", Tag: "carts", Summary: "Check out the caller's cart into an order, shipped and billed to the named or default addresses", Request: checkoutRequest{}, Response: models.Order{}, Status: http.StatusCreated, Authenticated: true},
+	{Method: http.MethodPost, Path: "/carts/:id/merge", Tag: "carts", Summary: "Merge a guest cart into the caller's cart", Request: mergeCartRequest{}, Response: models.Cart{}, Authenticated: true},
+	{Method: http.MethodGet, Path: "/carts/:id/shipping-quotes", Tag: "carts", Summary: "Shipping methods available for a cart, cheapest first", Query: []string{"region"}, Response: []models.ShippingQuote{}},
+	{Method: http.MethodPut, Path: "/carts/:id/shipping", Tag: "carts", Summary: "Choose the shipping method of a cart", Request: cartShippingRequest{}, Response: models.Cart{}},
+
+	{Method: http.MethodGet, Path: "/shipping-methods", Tag: "shipping", Summary: "List shipping methods with their rules", Response: []models.ShippingMethod{}},
+	{Method: http.MethodPost, Path: "/shipping-methods", Tag: "shipping", Summary: "Create a shipping method", Request: shippingMethodRequest{}, Response: models.ShippingMethod{}, Status: http.StatusCreated, Permission: string(auth.PermShippingManage)},
+	{Method: http.MethodPut, Path: "/shipping-methods/:id", Tag: "shipping", Summary: "Replace a shipping method and its rules", Request: shippingMethodRequest{}, Response: models.ShippingMethod{}, Permission: string(auth.PermShippingManage)},
+	{Method: http.MethodDelete, Path: "/shipping-methods/:id", Tag: "shipping", Summary: "Delete a shipping method", Response: messageResponse{}, Permission: string(auth.PermShippingManage)},
+
+	{Method: http.MethodPost, Path: "/coupons", Tag: "coupons", Summary: "Create a coupon", Request: couponRequest{}, Response: models.Coupon{}, Status: http.StatusCreated, Permission: string(auth.PermPromotionsManage)},
+	{Method: http.MethodGet, Path: "/coupons", Tag: "coupons", Summary: "List coupons", Response: []models.Coupon{}, Permission: string(auth.PermPromotionsManage)},
+	{Method: http.MethodDelete, Path: "/coupons/:id", Tag: "coupons", Summary: "Delete a coupon", Response: messageResponse{}, Permission: string(auth.PermPromotionsManage)},
+
+	{Method: http.MethodPost, Path: "/promotions", Tag: "promotions", Summary: "Create a promotion", Request: promotionRequest{}, Response: models.Promotion{}, Status: http.StatusCreated, Permission: string(auth.PermPromotionsManage)},
+	{Method: http.MethodGet, Path: "/promotions", Tag: "promotions", Summary: "List promotions active now, or all of them for promotion managers", Response: []models.Promotion{}},
+	{Method: http.MethodPut, Path: "/promotions/:id", Tag: "promotions", Summary: "Replace a promotion", Request: promotionRequest{}, Response: models.Promotion{}, Permission: string(auth.PermPromotionsManage)},
+	{Method: http.MethodDelete, Path: "/promotions/:id", Tag: "promotions", Summary: "Delete a promotion", Response: messageResponse{}, Permission: string(auth.PermPromotionsManage)},
+
+	{Method: http.MethodGet, Path: "/orders/:id", Tag: "orders", Summary: "Get one of the caller's orders, or any order with the orders:manage permission", Response: models.Order{}, Authenticated: true},
+
+	{Method: http.MethodPost, Path: "/wishlists", Tag: "wishlists", Summary: "Create a wishlist of the caller", Request: wishlistRequest{}, Response: models.Wishlist{}, Status: http.StatusCreated, Authenticated: true},
+	{Method: http.MethodGet, Path: "/wishlists", Tag: "wishlists", Summary: "List the caller's wishlists", Response: []models.Wishlist{}, Authenticated: true},
+	{Method: http.MethodGet, Path: "/wishlists/:id", Tag: "wishlists", Summary: "Get a wishlist", Response: models.Wishlist{}, Authenticated: true},
+	{Method: http.MethodDelete, Path: "/wishlists/:id", Tag: "wishlists", Summary: "Delete a wishlist", Response: messageResponse{}, Authenticated: true},
+	{Method: http.MethodPost, Path: "/wishlists/:wishlist_id/add-product/:product_id", Tag: "wishlists", Summary: "Add a product to a wishlist", Response: models.Wishlist{}, Authenticated: true},
+	{Method: http.MethodDelete, Path: "/wishlists/:wishlist_id/remove-product/:product_id", Tag: "wishlists", Summary: "Remove a product from a wishlist", Response: models.Wishlist{}, Authenticated: true},
+	{Method: http.MethodPost, Path: "/wishlists/:wishlist_id/move-to-cart/:product_id", Tag: "wishlists", Summary: "Move a wishlisted product into a cart", Request: moveToCartRequest{}, Response: models.Cart{}, Authenticated: true},
+
+	{Method: http.MethodGet, Path: "/graphql", Tag: "graphql", Summary: "Run a GraphQL query passed as ?query=", Query: []string{"query", "operationName"}, Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/graphql", Tag: "graphql", Summary: "Run a GraphQL query or mutation", Request: graphQLRequest{}, Response: map[string]interface{}{}},
+
+	{Method: http.MethodGet, Path: "/feeds/google-merchant.xml", Tag: "feeds", Summary: "Google Merchant Center product feed (RSS 2.0)"},
+	{Method: http.MethodGet, Path: "/feeds/ceneo.xml", Tag: "feeds", Summary: "Ceneo product feed"},
+	{Method: http.MethodGet, Path: "/feeds/products.csv", Tag: "feeds", Summary: "Product feed as CSV"},
+
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "docs", Summary: "This OpenAPI document"},
+	{Method: http.MethodGet, Path: "/docs", Tag: "docs", Summary: "API reference page"},
+
+	{Method: http.MethodGet, Path: "/metrics", Tag: "monitoring", Summary: "Prometheus metrics"},
+
+	{Method: http.MethodPost, Path: "/webhooks", Tag: "webhooks", Summary: "Subscribe a URL to events; the response holds the signing secret", Request: webhookRequest{}, Response: models.WebhookSubscription{}, Status: http.StatusCreated, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodGet, Path: "/webhooks", Tag: "webhooks", Summary: "List webhook subscriptions", Response: []models.WebhookSubscription{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodGet, Path: "/webhooks/events", Tag: "webhooks", Summary: "List event types", Response: webhookEventTypes{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodGet, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Get a webhook subscription", Response: models.WebhookSubscription{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodPut, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Update a webhook subscription", Request: webhookRequest{}, Response: models.WebhookSubscription{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodDelete, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Delete a webhook subscription", Response: messageResponse{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodGet, Path: "/webhooks/:id/deliveries", Tag: "webhooks", Summary: "Delivery log with attempts", Query: []string{"status", "page", "per_page"}, Response: webhookDeliveryPage{}, Permission: string(auth.PermWebhooksManage)},
+	{Method: http.MethodPost, Path: "/webhooks/:id/deliveries/:delivery_id/redeliver", Tag: "webhooks", Summary: "Send a delivery again", Response: models.WebhookDelivery{}, Status: http.StatusAccepted, Permission: string(auth.PermWebhooksManage)},
+
+	{Method: http.MethodPost, Path: "/auth/register", Tag: "auth", Summary: "Create a customer account", Request: registerRequest{}, Response: models.User{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/auth/login", Tag: "auth", Summary: "Exchange credentials for a bearer token", Request: loginRequest{}, Response: loginResponse{}},
+	{Method: http.MethodPost, Path: "/auth/logout", Tag: "auth", Summary: "Revoke the bearer token", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/auth/me", Tag: "auth", Summary: "The authenticated account", Response: models.User{}},
+
+	{Method: http.MethodGet, Path: "/addresses", Tag: "addresses", Summary: "The signed-in user's address book, defaults first", Response: []models.Address{}, Authenticated: true},
+	{Method: http.MethodPost, Path: "/addresses", Tag: "addresses", Summary: "Add an address; the first one is the default for shipping and billing", Request: addressRequest{}, Response: models.Address{}, Status: http.StatusCreated, Authenticated: true},
+	{Method: http.MethodGet, Path: "/addresses/:id", Tag: "addresses", Summary: "Get an address", Response: models.Address{}, Authenticated: true},
+	{Method: http.MethodPut, Path: "/addresses/:id", Tag: "addresses", Summary: "Replace an address or make it a default", Request: addressRequest{}, Response: models.Address{}, Authenticated: true},
+	{Method: http.MethodDelete, Path: "/addresses/:id", Tag: "addresses", Summary: "Delete an address", Response: messageResponse{}, Authenticated: true},
+
+	{Method: http.MethodDelete, Path: "/admin/cache", Tag: "admin", Summary: "Flush the catalogue cache", Response: cacheFlushResponse{}, Permission: string(auth.PermCacheFlush)},
+	{Method: http.MethodGet, Path: "/admin/users", Tag: "admin", Summary: "List accounts", Query: []string{"role"}, Response: []models.User{}, Permission: string(auth.PermUsersManage)},
+	{Method: http.MethodPut, Path: "/admin/users/:id/role", Tag: "admin", Summary: "Assign a role (customer, staff or admin)", Request: roleRequest{}, Response: models.User{}, Permission: string(auth.PermUsersManage)},
+	{Method: http.MethodPost, Path: "/admin/api-keys", Tag: "admin", Summary: "Issue an API key with some of the caller's permissions; the response holds the key", Request: apiKeyRequest{}, Response: models.APIKey{}, Status: http.StatusCreated, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodGet, Path: "/admin/api-keys", Tag: "admin", Summary: "List API keys with last-used times", Query: []string{"active"}, Response: []models.APIKey{}, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodGet, Path: "/admin/api-keys/scopes", Tag: "admin", Summary: "List permissions a key can be scoped to", Response: apiKeyScopes{}, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodPut, Path: "/admin/api-keys/:id", Tag: "admin", Summary: "Rename an API key or change its scopes and expiry", Request: apiKeyRequest{}, Response: models.APIKey{}, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodDelete, Path: "/admin/api-keys/:id", Tag: "admin", Summary: "Revoke an API key", Response: models.APIKey{}, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodPost, Path: "/admin/api-keys/:id/rotate", Tag: "admin", Summary: "Replace an API key's value; the response holds the new key", Response: models.APIKey{}, Permission: string(auth.PermAPIKeysManage)},
+	{Method: http.MethodPost, Path: "/admin/stores", Tag: "admin", Summary: "Add a storefront, from the default store", Request: storeRequest{}, Response: models.Store{}, Status: http.StatusCreated, Permission: string(auth.PermStoresManage)},
+	{Method: http.MethodGet, Path: "/admin/stores", Tag: "admin", Summary: "List storefronts, from the default store", Response: []models.Store{}, Permission: string(auth.PermStoresManage)},
+	{Method: http.MethodPut, Path: "/admin/stores/:id", Tag: "admin", Summary: "Change a storefront's slug, name or domain, from the default store", Request: storeRequest{}, Response: models.Store{}, Permission: string(auth.PermStoresManage)},
+	{Method: http.MethodDelete, Path: "/admin/stores/:id", Tag: "admin", Summary: "Take a storefront offline, from the default store", Response: messageResponse{}, Permission: string(auth.PermStoresManage)},
+}
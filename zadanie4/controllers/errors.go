@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+	"shop/tenant"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errCartNotFound    = errors.New("cart not found")
+	errProductNotFound = errors.New("product not found")
+	errCouponNotFound  = errors.New("coupon not found")
+	errCartEmpty       = errors.New("cart is empty")
+	errCartCheckedOut  = errors.New("cart already checked out")
+	errInvalidRegion   = errors.New("invalid region")
+	errReviewOtherUser = errors.New("review belongs to another user")
+)
+
+// errorStatus maps errors returned by the shared cart, coupon and product
+// logic to an HTTP status and the error code shown to clients.
+func errorStatus(err error) (int, i18n.Code) {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized, i18n.CodeUnauthenticated
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden, i18n.CodeForbidden
+	case errors.Is(err, tenant.ErrNoStore):
+		return http.StatusNotFound, i18n.CodeStoreNotFound
+	case errors.Is(err, errInvalidRegion):
+		return http.StatusBadRequest, i18n.CodeInvalidRegion
+	case errors.Is(err, errCartNotFound):
+		return http.StatusNotFound, i18n.CodeCartNotFound
+	case errors.Is(err, errProductNotFound):
+		return http.StatusNotFound, i18n.CodeProductNotFound
+	case errors.Is(err, errCouponNotFound):
+		return http.StatusNotFound, i18n.CodeCouponNotFound
+	case errors.Is(err, errCartCheckedOut):
+		return http.StatusConflict, i18n.CodeCartCheckedOut
+	case errors.Is(err, errReviewOtherUser):
+		return http.StatusForbidden, i18n.CodeReviewOtherUser
+	case errors.Is(err, errAddressNotFound):
+		return http.StatusNotFound, i18n.CodeAddressNotFound
+	case errors.Is(err, errShippingMethodNotFound):
+		return http.StatusNotFound, i18n.CodeShippingMethodNotFound
+	case errors.Is(err, errShippingUnavailable):
+		return http.StatusUnprocessableEntity, i18n.CodeShippingUnavailable
+	case errors.Is(err, errCartEmpty):
+		return http.StatusUnprocessableEntity, i18n.CodeCartEmpty
+	case errors.Is(err, models.ErrCouponNotActive):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponNotActive
+	case errors.Is(err, models.ErrCouponMinCartValue):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponMinCartValue
+	case errors.Is(err, models.ErrCouponNotApplicable):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponNotApplicable
+	case errors.Is(err, models.ErrCouponUsageLimit):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponUsageLimit
+	case errors.Is(err, models.ErrCouponUserLimit):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponUserLimit
+	case errors.Is(err, models.ErrCouponRequiresUser):
+		return http.StatusUnprocessableEntity, i18n.CodeCouponRequiresUser
+	}
+	return http.StatusInternalServerError, i18n.CodeInternal
+}
+
+func errorResponse(c echo.Context, err error) error {
+	status, code := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		return failure(c, status, code, err)
+	}
+	return errorJSON(c, status, code)
+}
+
+// failure logs err, which may carry details clients should not see, and
+// responds with the generic message for code. A request for data of a
+// store that does not exist is reported as such rather than as code.
+func failure(c echo.Context, status int, code i18n.Code, err error) error {
+	if errors.Is(err, tenant.ErrNoStore) {
+		status, code = http.StatusNotFound, i18n.CodeStoreNotFound
+	}
+	log := logging.FromContext(c.Request().Context())
+	if status >= http.StatusInternalServerError {
+		log.Error("request failed", "error", err)
+	} else {
+		log.Warn("request failed", "error", err)
+	}
+	return errorJSON(c, status, code)
+}
+
+// errorJSON writes an error body with the stable code, its message in the
+// language negotiated from Accept-Language and the request ID, so a failed
+// call can be matched with the server logs.
+func errorJSON(c echo.Context, status int, code i18n.Code) error {
+	return c.JSON(status, logging.ErrorBody(c, code))
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+
+	"shop/cache"
+	"shop/feeds"
+	"shop/i18n"
+	"shop/models"
+	"shop/tenant"
+
+	"github.com/labstack/echo/v4"
+)
+
+// feedCache holds rendered feeds until the catalogue changes. Like the
+// product caches it is nil, and so disabled, until ConfigureCatalogCache.
+var feedCache *cache.Cache[feedKey, []byte]
+
+type feedKey struct {
+	StoreID uint
+	Name    string
+	BaseURL string
+}
+
+var (
+	feedBaseURL  string
+	feedCurrency = "PLN"
+)
+
+// ConfigureFeeds sets the storefront address used in feed links for stores
+// without a domain of their own, and the currency of feed prices. An empty
+// baseURL uses the address the feed was requested at.
+func ConfigureFeeds(baseURL, currency string) {
+	feedBaseURL = baseURL
+	feedCurrency = currency
+}
+
+func GetGoogleMerchantFeed(c echo.Context) error {
+	return serveFeed(c, "google-merchant", feeds.GoogleMerchant)
+}
+
+func GetCeneoFeed(c echo.Context) error {
+	return serveFeed(c, "ceneo", feeds.Ceneo)
+}
+
+func GetCSVFeed(c echo.Context) error {
+	return serveFeed(c, "csv", feeds.CSV)
+}
+
+// serveFeed renders the request store's products in format, reusing the
+// last rendering until a catalogue write invalidates it.
+func serveFeed(c echo.Context, name string, format feeds.Format) error {
+	ctx := c.Request().Context()
+	store, _ := tenant.FromContext(ctx)
+	opts := feeds.Options{Title: store.Name, BaseURL: storeBaseURL(c, store), Currency: feedCurrency}
+
+	body, hit, err := feedCache.Load(feedKey{store.ID, name, opts.BaseURL}, func() ([]byte, error) {
+		products, err := listProducts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The cached list is shared, so sort a copy.
+		sorted := append([]models.Product(nil), products...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+		var buf bytes.Buffer
+		if err := format.Render(&buf, sorted, opts); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	recordCacheLookup("feed", hit)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.Blob(http.StatusOK, format.ContentType, body)
+}
+
+// storeBaseURL is the storefront address of store: its own domain, else
+// the configured one, else the host the request was made to.
+func storeBaseURL(c echo.Context, store models.Store) string {
+	switch {
+	case store.Domain != "":
+		return "https://" + store.Domain
+	case feedBaseURL != "":
+		return feedBaseURL
+	default:
+		return c.Scheme() + "://" + c.Request().Host
+	}
+}
@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+)
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+var graphQLSchema = mustBuildGraphQLSchema()
+
+// GraphQL executes a query against the catalogue and cart schema. Queries
+// can be sent as JSON via POST or as ?query= via GET.
+func GraphQL(c echo.Context) error {
+	req := new(graphQLRequest)
+	if c.Request().Method == http.MethodGet {
+		req.Query = c.QueryParam("query")
+		req.OperationName = c.QueryParam("operationName")
+	} else if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if req.Query == "" {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeGraphQLQueryRequired)
+	}
+
+	ctx := context.WithValue(c.Request().Context(), loadersKey{}, newLoaders(c.Request().Context()))
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func mustBuildGraphQLSchema() graphql.Schema {
+	categoryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveCategoryField(func(c models.Category) interface{} { return c.ID })},
+			"name": &graphql.Field{Type: graphql.String, Resolve: resolveCategoryField(func(c models.Category) interface{} { return c.Name })},
+		},
+	})
+
+	productType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveProductField(func(p models.Product) interface{} { return p.ID })},
+			"name":          &graphql.Field{Type: graphql.String, Resolve: resolveProductField(func(p models.Product) interface{} { return p.Name })},
+			"description":   &graphql.Field{Type: graphql.String, Resolve: resolveProductField(func(p models.Product) interface{} { return p.Description })},
+			"price":         &graphql.Field{Type: graphql.Float, Resolve: resolveProductField(func(p models.Product) interface{} { return p.Price })},
+			"categoryId":    &graphql.Field{Type: graphql.ID, Resolve: resolveProductField(func(p models.Product) interface{} { return p.CategoryID })},
+			"averageRating": &graphql.Field{Type: graphql.Float, Resolve: resolveProductField(func(p models.Product) interface{} { return p.AverageRating })},
+			"reviewCount":   &graphql.Field{Type: graphql.Int, Resolve: resolveProductField(func(p models.Product) interface{} { return p.ReviewCount })},
+			"category": &graphql.Field{
+				Type: categoryType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					product, _ := p.Source.(models.Product)
+					if product.CategoryID == 0 {
+						return nil, nil
+					}
+					return loadersFrom(p.Context).categories.Load(product.CategoryID), nil
+				},
+			},
+		},
+	})
+
+	categoryType.AddFieldConfig("products", &graphql.Field{
+		Type: graphql.NewList(productType),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			category, _ := p.Source.(models.Category)
+			return loadersFrom(p.Context).categoryProducts.Load(category.ID), nil
+		},
+	})
+
+	appliedPromotionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AppliedPromotion",
+		Fields: graphql.Fields{
+			"promotionId": &graphql.Field{Type: graphql.ID, Resolve: resolvePromotionField(func(a models.AppliedPromotion) interface{} { return a.PromotionID })},
+			"name":        &graphql.Field{Type: graphql.String, Resolve: resolvePromotionField(func(a models.AppliedPromotion) interface{} { return a.Name })},
+			"description": &graphql.Field{Type: graphql.String, Resolve: resolvePromotionField(func(a models.AppliedPromotion) interface{} { return a.Description })},
+			"discount":    &graphql.Field{Type: graphql.Float, Resolve: resolvePromotionField(func(a models.AppliedPromotion) interface{} { return a.Discount })},
+		},
+	})
+
+	cartType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Cart",
+		Fields: graphql.Fields{
+			"id":                &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolveCartField(func(c models.Cart) interface{} { return c.ID })},
+			"userId":            &graphql.Field{Type: graphql.ID, Resolve: resolveCartField(func(c models.Cart) interface{} { return c.UserID })},
+			"products":          &graphql.Field{Type: graphql.NewList(productType), Resolve: resolveCartField(func(c models.Cart) interface{} { return c.Products })},
+			"promotions":        &graphql.Field{Type: graphql.NewList(appliedPromotionType), Resolve: resolveCartField(func(c models.Cart) interface{} { return c.Promotions })},
+			"subtotal":          &graphql.Field{Type: graphql.Float, Resolve: resolveCartField(func(c models.Cart) interface{} { return c.Subtotal })},
+			"promotionDiscount": &graphql.Field{Type: graphql.Float, Resolve: resolveCartField(func(c models.Cart) interface{} { return c.PromotionDiscount })},
+			"discount":          &graphql.Field{Type: graphql.Float, Resolve: resolveCartField(func(c models.Cart) interface{} { return c.Discount })},
+			"total":             &graphql.Field{Type: graphql.Float, Resolve: resolveCartField(func(c models.Cart) interface{} { return c.Total })},
+		},
+	})
+
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+	cartProductArgs := graphql.FieldConfigArgument{
+		"cartId":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"productId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+	productInputArgs := graphql.FieldConfigArgument{
+		"name":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.ArgumentConfig{Type: graphql.String},
+		"price":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+		"categoryId":  &graphql.ArgumentConfig{Type: graphql.ID},
+	}
+	updateProductArgs := graphql.FieldConfigArgument{"id": idArgs["id"]}
+	for name, arg := range productInputArgs {
+		updateProductArgs[name] = arg
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{
+				Type: graphql.NewList(productType),
+				Args: graphql.FieldConfigArgument{
+					"minPrice":   &graphql.ArgumentConfig{Type: graphql.Float},
+					"categoryId": &graphql.ArgumentConfig{Type: graphql.ID},
+				},
+				Resolve: resolveProducts,
+			},
+			"product": &graphql.Field{Type: productType, Args: idArgs, Resolve: resolveProduct},
+			"categories": &graphql.Field{
+				Type: graphql.NewList(categoryType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var categories []models.Category
+					err := requestDB(p.Context).Order("id").Find(&categories).Error
+					return categories, err
+				},
+			},
+			"category": &graphql.Field{
+				Type: categoryType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var category models.Category
+					if err := requestDB(p.Context).First(&category, p.Args["id"]).Error; err != nil {
+						return nil, errors.New("category not found")
+					}
+					return category, nil
+				},
+			},
+			"cart": &graphql.Field{
+				Type: cartType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var cart models.Cart
+					if err := loadCart(p.Context, &cart, p.Args["id"]); err != nil {
+						return nil, err
+					}
+					return cart, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createProduct": &graphql.Field{Type: productType, Args: productInputArgs, Resolve: requirePermission(auth.PermCatalogWrite, resolveCreateProduct)},
+			"updateProduct": &graphql.Field{Type: productType, Args: updateProductArgs, Resolve: requirePermission(auth.PermCatalogWrite, resolveUpdateProduct)},
+			"deleteProduct": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: idArgs,
+				Resolve: requirePermission(auth.PermCatalogWrite, func(p graphql.ResolveParams) (interface{}, error) {
+					if err := deleteProduct(p.Context, p.Args["id"]); err != nil {
+						return nil, err
+					}
+					return true, nil
+				}),
+			},
+			"createCart": &graphql.Field{
+				Type: cartType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					cart := models.Cart{UserID: callerUserID(p.Context)}
+					if err := createCart(p.Context, &cart); err != nil {
+						return nil, err
+					}
+					return cartWithTotals(p.Context, cart.ID)
+				},
+			},
+			"addProductToCart": &graphql.Field{
+				Type: cartType,
+				Args: cartProductArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					cart, err := addProductToCart(p.Context, p.Args["cartId"].(string), p.Args["productId"].(string))
+					if err != nil {
+						return nil, err
+					}
+					return cartWithTotals(p.Context, cart.ID)
+				},
+			},
+			"removeProductFromCart": &graphql.Field{
+				Type: cartType,
+				Args: cartProductArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					cart, err := removeProductFromCart(p.Context, p.Args["cartId"].(string), p.Args["productId"].(string))
+					if err != nil {
+						return nil, err
+					}
+					return cartWithTotals(p.Context, cart.ID)
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func resolveProducts(p graphql.ResolveParams) (interface{}, error) {
+	ctx := p.Context
+	db := requestDB(ctx).Order("id")
+	if minPrice, ok := p.Args["minPrice"].(float64); ok {
+		db = db.Scopes(ScopeMinPrice(minPrice))
+	}
+	if categoryID, ok := p.Args["categoryId"].(string); ok {
+		db = db.Where("category_id = ?", categoryID)
+	}
+
+	var products []models.Product
+	if err := db.Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if err := attachRatings(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func resolveProduct(p graphql.ResolveParams) (interface{}, error) {
+	ctx := p.Context
+	var product models.Product
+	if err := requestDB(ctx).First(&product, p.Args["id"]).Error; err != nil {
+		return nil, errProductNotFound
+	}
+
+	products := []models.Product{product}
+	if err := attachRatings(ctx, products); err != nil {
+		return nil, err
+	}
+	return products[0], nil
+}
+
+// requirePermission guards a resolver like auth.Require guards a route.
+func requirePermission(permission auth.Permission, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if err := auth.Check(p.Context, permission); err != nil {
+			return nil, err
+		}
+		return resolve(p)
+	}
+}
+
+func resolveCreateProduct(p graphql.ResolveParams) (interface{}, error) {
+	ctx := p.Context
+	product := models.Product{}
+	applyProductArgs(ctx, &product, p.Args)
+
+	if err := createProduct(ctx, &product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func resolveUpdateProduct(p graphql.ResolveParams) (interface{}, error) {
+	ctx := p.Context
+	data := models.Product{}
+	applyProductArgs(ctx, &data, p.Args)
+
+	product, err := updateProduct(ctx, p.Args["id"], &data)
+	if err != nil {
+		return nil, err
+	}
+	return *product, nil
+}
+
+func applyProductArgs(ctx context.Context, product *models.Product, args map[string]interface{}) {
+	product.Name, _ = args["name"].(string)
+	product.Description, _ = args["description"].(string)
+	product.Price, _ = args["price"].(float64)
+	product.CategoryID = 0
+	if categoryID, ok := args["categoryId"].(string); ok {
+		var category models.Category
+		if requestDB(ctx).First(&category, categoryID).Error == nil {
+			product.CategoryID = category.ID
+		}
+	}
+}
+
+func cartWithTotals(ctx context.Context, id uint) (interface{}, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, id); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func resolveProductField(get func(models.Product) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		product, _ := p.Source.(models.Product)
+		return get(product), nil
+	}
+}
+
+func resolveCategoryField(get func(models.Category) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		category, _ := p.Source.(models.Category)
+		return get(category), nil
+	}
+}
+
+func resolveCartField(get func(models.Cart) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		cart, _ := p.Source.(models.Cart)
+		return get(cart), nil
+	}
+}
+
+func resolvePromotionField(get func(models.AppliedPromotion) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		applied, _ := p.Source.(models.AppliedPromotion)
+		return get(applied), nil
+	}
+}
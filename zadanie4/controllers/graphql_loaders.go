@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"shop/models"
+)
+
+type loadersKey struct{}
+
+// loaders batch the lookups made while resolving one GraphQL request, so a
+// list of products costs one category query instead of one per product.
+type loaders struct {
+	categories       *batchLoader[uint, models.Category]
+	categoryProducts *batchLoader[uint, []models.Product]
+}
+
+func newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		categories: newBatchLoader(func(ids []uint) (map[uint]models.Category, error) {
+			return fetchCategories(ctx, ids)
+		}),
+		categoryProducts: newBatchLoader(func(ids []uint) (map[uint][]models.Product, error) {
+			return fetchCategoryProducts(ctx, ids)
+		}),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*loaders); ok {
+		return l
+	}
+	return newLoaders(ctx)
+}
+
+// batchLoader collects keys requested by resolvers and fetches all pending
+// keys at once when the first returned thunk is evaluated.
+type batchLoader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func(keys []K) (map[K]V, error)
+	pending []K
+	queued  map[K]bool
+	results map[K]V
+	errs    map[K]error
+}
+
+func newBatchLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{
+		fetch:   fetch,
+		queued:  map[K]bool{},
+		results: map[K]V{},
+		errs:    map[K]error{},
+	}
+}
+
+// Load returns a graphql-go thunk resolving to the value for key.
+func (l *batchLoader[K, V]) Load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+
+			values, err := l.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+					continue
+				}
+				l.results[k] = values[k]
+			}
+		}
+
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		return l.results[key], nil
+	}
+}
+
+func fetchCategories(ctx context.Context, ids []uint) (map[uint]models.Category, error) {
+	var categories []models.Category
+	if err := requestDB(ctx).Find(&categories, ids).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	return byID, nil
+}
+
+func fetchCategoryProducts(ctx context.Context, categoryIDs []uint) (map[uint][]models.Product, error) {
+	var products []models.Product
+	if err := requestDB(ctx).Where("category_id IN ?", categoryIDs).Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if err := attachRatings(ctx, products); err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[uint][]models.Product, len(categoryIDs))
+	for _, id := range categoryIDs {
+		byCategory[id] = []models.Product{}
+	}
+	for _, product := range products {
+		byCategory[product.CategoryID] = append(byCategory[product.CategoryID], product)
+	}
+	return byCategory, nil
+}
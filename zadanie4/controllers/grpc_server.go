@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+	"shop/shoppb"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RegisterGRPCServices exposes the catalogue and cart operations over gRPC.
+// The services call the same functions as the REST handlers, so both APIs
+// share validation, totals and checkout rules.
+func RegisterGRPCServices(s *grpc.Server) {
+	shoppb.RegisterCatalogServiceServer(s, catalogServer{})
+	shoppb.RegisterCartServiceServer(s, cartServer{})
+}
+
+type catalogServer struct {
+	shoppb.UnimplementedCatalogServiceServer
+}
+
+func (catalogServer) ListProducts(ctx context.Context, req *shoppb.ListProductsRequest) (*shoppb.ListProductsResponse, error) {
+	db := requestDB(ctx).Preload("Category").Order("id").Scopes(ScopeMinPrice(req.MinPrice))
+	if req.CategoryId != 0 {
+		db = db.Where("category_id = ?", req.CategoryId)
+	}
+
+	var products []models.Product
+	if err := db.Find(&products).Error; err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if err := attachRatings(ctx, products); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &shoppb.ListProductsResponse{}
+	for _, product := range products {
+		resp.Products = append(resp.Products, productToProto(product))
+	}
+	return resp, nil
+}
+
+func (catalogServer) GetProduct(ctx context.Context, req *shoppb.GetProductRequest) (*shoppb.Product, error) {
+	product, err := findProduct(ctx, uint(req.Id))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return productToProto(product), nil
+}
+
+func (catalogServer) CreateProduct(ctx context.Context, req *shoppb.CreateProductRequest) (*shoppb.Product, error) {
+	product := models.Product{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  uint(req.CategoryId),
+	}
+	if err := createProduct(ctx, &product); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return productToProto(product), nil
+}
+
+func (catalogServer) UpdateProduct(ctx context.Context, req *shoppb.UpdateProductRequest) (*shoppb.Product, error) {
+	product, err := updateProduct(ctx, req.Id, &models.Product{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		CategoryID:  uint(req.CategoryId),
+	})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return productToProto(*product), nil
+}
+
+func (catalogServer) DeleteProduct(ctx context.Context, req *shoppb.DeleteProductRequest) (*shoppb.DeleteProductResponse, error) {
+	if err := deleteProduct(ctx, req.Id); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &shoppb.DeleteProductResponse{}, nil
+}
+
+func (catalogServer) ListCategories(ctx context.Context, req *shoppb.ListCategoriesRequest) (*shoppb.ListCategoriesResponse, error) {
+	var categories []models.Category
+	if err := requestDB(ctx).Order("id").Find(&categories).Error; err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &shoppb.ListCategoriesResponse{}
+	for _, category := range categories {
+		resp.Categories = append(resp.Categories, categoryToProto(category))
+	}
+	return resp, nil
+}
+
+type cartServer struct {
+	shoppb.UnimplementedCartServiceServer
+}
+
+func (cartServer) CreateCart(ctx context.Context, req *shoppb.CreateCartRequest) (*shoppb.Cart, error) {
+	cart := models.Cart{UserID: callerUserID(ctx), Region: strings.ToUpper(strings.TrimSpace(req.Region))}
+	if cart.Region != "" && !regionPattern.MatchString(cart.Region) {
+		return nil, grpcError(ctx, errInvalidRegion)
+	}
+	if err := createCart(ctx, &cart); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartResponse(ctx, cart.ID)
+}
+
+func (cartServer) GetCart(ctx context.Context, req *shoppb.GetCartRequest) (*shoppb.Cart, error) {
+	return cartResponse(ctx, req.Id)
+}
+
+func (cartServer) AddProduct(ctx context.Context, req *shoppb.CartProductRequest) (*shoppb.Cart, error) {
+	cart, err := addProductToCart(ctx, formatID(req.CartId), formatID(req.ProductId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartResponse(ctx, cart.ID)
+}
+
+func (cartServer) RemoveProduct(ctx context.Context, req *shoppb.CartProductRequest) (*shoppb.Cart, error) {
+	cart, err := removeProductFromCart(ctx, formatID(req.CartId), formatID(req.ProductId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartResponse(ctx, cart.ID)
+}
+
+func (cartServer) ApplyCoupon(ctx context.Context, req *shoppb.ApplyCouponRequest) (*shoppb.Cart, error) {
+	cart, err := applyCoupon(ctx, req.CartId, req.Code)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartToProto(*cart), nil
+}
+
+func (cartServer) RemoveCoupon(ctx context.Context, req *shoppb.GetCartRequest) (*shoppb.Cart, error) {
+	cart, err := removeCoupon(ctx, req.Id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartToProto(*cart), nil
+}
+
+func (cartServer) ListShippingQuotes(ctx context.Context, req *shoppb.ListShippingQuotesRequest) (*shoppb.ListShippingQuotesResponse, error) {
+	region := strings.ToUpper(strings.TrimSpace(req.Region))
+	if region != "" && !regionPattern.MatchString(region) {
+		return nil, grpcError(ctx, errInvalidRegion)
+	}
+
+	quotes, err := cartShippingQuotes(ctx, req.CartId, region)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &shoppb.ListShippingQuotesResponse{}
+	for _, quote := range quotes {
+		resp.Quotes = append(resp.Quotes, &shoppb.ShippingQuote{
+			ShippingMethodId: uint64(quote.ShippingMethodID),
+			Code:             quote.Code,
+			Name:             quote.Name,
+			Kind:             quote.Kind,
+			Price:            quote.Price,
+		})
+	}
+	return resp, nil
+}
+
+func (cartServer) SetShippingMethod(ctx context.Context, req *shoppb.SetShippingMethodRequest) (*shoppb.Cart, error) {
+	cart, err := setCartShippingMethod(ctx, req.CartId, optionalID(req.ShippingMethodId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartToProto(*cart), nil
+}
+
+func (cartServer) Checkout(ctx context.Context, req *shoppb.CheckoutRequest) (*shoppb.Order, error) {
+	order, err := checkoutCart(ctx, req.CartId, checkoutRequest{
+		ShippingAddressID: optionalID(req.ShippingAddressId),
+		BillingAddressID:  optionalID(req.BillingAddressId),
+	})
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return orderToProto(*order), nil
+}
+
+func cartResponse(ctx context.Context, id interface{}) (*shoppb.Cart, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, id); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return cartToProto(cart), nil
+}
+
+// grpcError translates the errors shared with the REST handlers into gRPC
+// status codes. The stable error code travels as ErrorInfo.Reason and the
+// message follows the accept-language metadata like the REST responses.
+func grpcError(ctx context.Context, err error) error {
+	httpStatus, reason := errorStatus(err)
+
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusUnprocessableEntity:
+		code = codes.FailedPrecondition
+	default:
+		logging.FromContext(ctx).Error("request failed", "error", err)
+	}
+
+	lang := i18n.English
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		lang = i18n.Negotiate(strings.Join(md.Get("accept-language"), ","), i18n.English)
+	}
+
+	st := status.New(code, i18n.Message(lang, reason))
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(reason), Domain: "shop"}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func formatID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// optionalID converts an optional proto ID to the pointer form the shared
+// logic takes.
+func optionalID(id *uint64) *uint {
+	if id == nil {
+		return nil
+	}
+	v := uint(*id)
+	return &v
+}
+
+func categoryToProto(category models.Category) *shoppb.Category {
+	return &shoppb.Category{
+		Id:   uint64(category.ID),
+		Name: category.Name,
+	}
+}
+
+func productToProto(product models.Product) *shoppb.Product {
+	pb := &shoppb.Product{
+		Id:            uint64(product.ID),
+		Name:          product.Name,
+		Description:   product.Description,
+		Price:         product.Price,
+		CategoryId:    uint64(product.CategoryID),
+		AverageRating: product.AverageRating,
+		ReviewCount:   product.ReviewCount,
+	}
+	if product.Category.ID != 0 {
+		pb.Category = categoryToProto(product.Category)
+	}
+	return pb
+}
+
+func cartToProto(cart models.Cart) *shoppb.Cart {
+	pb := &shoppb.Cart{
+		Id:                uint64(cart.ID),
+		UserId:            uint64(cart.UserID),
+		Subtotal:          cart.Subtotal,
+		PromotionDiscount: cart.PromotionDiscount,
+		Discount:          cart.Discount,
+		Total:             cart.Total,
+		Region:            cart.Region,
+		Weight:            cart.Weight,
+		Shipping:          cart.Shipping,
+		Net:               cart.Net,
+		Tax:               cart.Tax,
+		TaxLines:          taxLinesToProto(cart.TaxLines),
+	}
+	if cart.Coupon != nil {
+		pb.CouponCode = cart.Coupon.Code
+	}
+	if cart.ShippingMethodID != nil {
+		pb.ShippingMethodId = uint64(*cart.ShippingMethodID)
+	}
+	if cart.ShippingMethod != nil {
+		pb.ShippingMethod = cart.ShippingMethod.Name
+	}
+	for _, product := range cart.Products {
+		pb.Products = append(pb.Products, productToProto(product))
+	}
+	for _, item := range cart.Items {
+		pb.Items = append(pb.Items, &shoppb.CartItem{
+			ProductId: uint64(item.ProductID),
+			Quantity:  int32(item.Quantity),
+		})
+	}
+	for _, promotion := range cart.Promotions {
+		applied := &shoppb.AppliedPromotion{
+			PromotionId: uint64(promotion.PromotionID),
+			Name:        promotion.Name,
+			Description: promotion.Description,
+			Discount:    promotion.Discount,
+		}
+		for _, id := range promotion.ProductIDs {
+			applied.ProductIds = append(applied.ProductIds, uint64(id))
+		}
+		pb.Promotions = append(pb.Promotions, applied)
+	}
+	return pb
+}
+
+func orderToProto(order models.Order) *shoppb.Order {
+	pb := &shoppb.Order{
+		Id:                uint64(order.ID),
+		CartId:            uint64(order.CartID),
+		UserId:            uint64(order.UserID),
+		CouponCode:        order.CouponCode,
+		Subtotal:          order.Subtotal,
+		PromotionDiscount: order.PromotionDiscount,
+		Discount:          order.Discount,
+		Total:             order.Total,
+		Region:            order.Region,
+		ShippingMethod:    order.ShippingMethod,
+		Weight:            order.Weight,
+		Shipping:          order.Shipping,
+		Net:               order.Net,
+		Tax:               order.Tax,
+		ShippingAddress:   addressToProto(order.ShippingAddress),
+		BillingAddress:    addressToProto(order.BillingAddress),
+	}
+	if order.ShippingMethodID != nil {
+		pb.ShippingMethodId = uint64(*order.ShippingMethodID)
+	}
+	for _, item := range order.Items {
+		pb.Items = append(pb.Items, &shoppb.OrderItem{
+			ProductId: uint64(item.ProductID),
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  int32(item.Quantity),
+			TaxRate:   item.TaxRate,
+		})
+	}
+	for _, line := range order.TaxLines {
+		pb.TaxLines = append(pb.TaxLines, taxLineToProto(line.TaxLine))
+	}
+	return pb
+}
+
+func taxLinesToProto(lines []models.TaxLine) []*shoppb.TaxLine {
+	var pb []*shoppb.TaxLine
+	for _, line := range lines {
+		pb = append(pb, taxLineToProto(line))
+	}
+	return pb
+}
+
+func taxLineToProto(line models.TaxLine) *shoppb.TaxLine {
+	return &shoppb.TaxLine{
+		Class: line.Class,
+		Rate:  line.Rate,
+		Net:   line.Net,
+		Tax:   line.Tax,
+		Gross: line.Gross,
+	}
+}
+
+func addressToProto(address models.PostalAddress) *shoppb.PostalAddress {
+	return &shoppb.PostalAddress{
+		Name:       address.Name,
+		Company:    address.Company,
+		Street:     address.Street,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		Phone:      address.Phone,
+	}
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/metrics"
+	"shop/models"
+	"shop/shipping"
+	"shop/webhooks"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkoutRequest names addresses of the cart user's address book; both
+// are optional.
+type checkoutRequest struct {
+	ShippingAddressID *uint `json:"shipping_address_id"`
+	BillingAddressID  *uint `json:"billing_address_id"`
+}
+
+func CheckoutCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(checkoutRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	order, err := checkoutCart(ctx, c.Param("id"), *req)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+func checkoutCart(ctx context.Context, id interface{}, req checkoutRequest) (*models.Order, error) {
+	order, err := placeOrder(ctx, id, req)
+	if err != nil {
+		metrics.OrdersFailed.Inc()
+		return nil, err
+	}
+
+	metrics.OrdersCompleted.Inc()
+	metrics.Revenue.Add(order.Total)
+	return order, nil
+}
+
+// placeOrder turns a cart into an order, re-validating its coupon and
+// recording the redemption so usage limits take effect. The order keeps a
+// copy of its shipping and billing addresses. Only the cart's user and
+// order managers may check it out.
+func placeOrder(ctx context.Context, id interface{}, req checkoutRequest) (*models.Order, error) {
+	if auth.FromContext(ctx) == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, id); err != nil {
+		return nil, err
+	}
+	if !canAccessOrders(ctx, cart.UserID) {
+		return nil, errCartNotFound
+	}
+	if len(cart.Products) == 0 {
+		return nil, errCartEmpty
+	}
+	units := cart.Units()
+
+	checkedOut, err := isCheckedOut(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	if checkedOut {
+		return nil, errCartCheckedOut
+	}
+
+	if cart.Coupon != nil {
+		if _, err := cart.Coupon.Discount(units, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if cart.ShippingMethod != nil {
+		if _, ok := shipping.Quote(*cart.ShippingMethod, units, cartValue(cart), cart.Region); !ok {
+			return nil, errShippingUnavailable
+		}
+	}
+
+	shippingAddress, billingAddress, err := orderAddresses(ctx, cart.UserID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	order := models.Order{
+		CartID:   cart.ID,
+		UserID:   cart.UserID,
+		Subtotal: cart.Subtotal,
+		Discount: cart.Discount,
+		Total:    cart.Total,
+
+		PromotionDiscount: cart.PromotionDiscount,
+
+		ShippingAddress: shippingAddress,
+		BillingAddress:  billingAddress,
+
+		ShippingMethodID: cart.ShippingMethodID,
+		Shipping:         cart.Shipping,
+		Weight:           cart.Weight,
+
+		Region: cart.Region,
+		Net:    cart.Net,
+		Tax:    cart.Tax,
+	}
+	if cart.ShippingMethod != nil {
+		order.ShippingMethod = cart.ShippingMethod.Name
+	}
+	for _, line := range cart.TaxLines {
+		order.TaxLines = append(order.TaxLines, models.OrderTaxLine{TaxLine: line})
+	}
+
+	table, err := loadTaxTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range cart.Products {
+		order.Items = append(order.Items, models.OrderItem{
+			ProductID: product.ID,
+			Name:      product.Name,
+			Price:     product.Price,
+			Quantity:  cart.Quantity(product.ID),
+			TaxRate:   table.Rate(table.ClassOf(product), cart.Region),
+		})
+	}
+
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if cart.Coupon != nil {
+			order.CouponCode = cart.Coupon.Code
+			// Concurrent checkouts with the coupon wait here until this
+			// one commits, so they count its redemption.
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Coupon{}, cart.Coupon.ID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCouponNotFound
+			} else if err != nil {
+				return err
+			}
+			if err := checkCouponUsage(tx, cart.Coupon, cart.UserID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		if cart.Coupon != nil {
+			redemption := models.CouponRedemption{
+				CouponID: cart.Coupon.ID,
+				UserID:   cart.UserID,
+				OrderID:  order.ID,
+			}
+			if err := tx.Create(&redemption).Error; err != nil {
+				return err
+			}
+		}
+
+		return webhooks.Enqueue(tx, webhooks.OrderPlaced, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// canAccessOrders reports whether the caller may check out carts and read
+// orders of the user: they are that user or manage orders. Other callers
+// are told the cart or order does not exist, so IDs cannot be probed.
+func canAccessOrders(ctx context.Context, userID uint) bool {
+	principal := auth.FromContext(ctx)
+	if principal.Can(auth.PermOrdersManage) {
+		return true
+	}
+	return principal != nil && principal.UserID != 0 && principal.UserID == userID
+}
+
+func GetOrderByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	if auth.FromContext(ctx) == nil {
+		return errorResponse(c, auth.ErrUnauthenticated)
+	}
+
+	var order models.Order
+	if err := requestDB(ctx).Preload("Items").Preload("TaxLines").First(&order, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeOrderNotFound)
+	}
+	if !canAccessOrders(ctx, order.UserID) {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeOrderNotFound)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
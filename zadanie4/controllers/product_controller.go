@@ -1,87 +1,167 @@
 package controllers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
-	"shop/config"
+	"shop/i18n"
 	"shop/models"
+	"shop/webhooks"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
 func CreateProduct(c echo.Context) error {
+	ctx := c.Request().Context()
 	product := new(models.Product)
 	if err := c.Bind(product); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
 	}
 
-	if err := config.DB.Create(product).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	if err := createProduct(ctx, product); err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
 	}
 
 	return c.JSON(http.StatusCreated, product)
 }
 
 func GetProducts(c echo.Context) error {
-	var products []models.Product
-	if err := config.DB.Preload("Category").Find(&products).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	ctx := c.Request().Context()
+	products, err := listProducts(ctx)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	products, err = withTaxPrices(c, products)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
 	}
 
 	return c.JSON(http.StatusOK, products)
 }
 
 func GetProductByID(c echo.Context) error {
-	id := c.Param("id")
-	var product models.Product
+	ctx := c.Request().Context()
+	id, err := parseProductID(c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	product, err := findProduct(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
 
-	if err := config.DB.Preload("Category").First(&product, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Product not found"})
+	priced, err := withTaxPrices(c, []models.Product{product})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
 	}
 
-	return c.JSON(http.StatusOK, product)
+	return c.JSON(http.StatusOK, priced[0])
 }
 
 func UpdateProduct(c echo.Context) error {
+	ctx := c.Request().Context()
 	id := c.Param("id")
 
 	var product models.Product
-	if err := config.DB.First(&product, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Product not found"})
+	if err := requestDB(ctx).First(&product, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotFound)
 	}
 
 	updateData := new(models.Product)
 	if err := c.Bind(updateData); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
 	}
 
-	product.Name = updateData.Name
-	product.Description = updateData.Description
-	product.Price = updateData.Price
-	product.CategoryID = updateData.CategoryID
-
-	if err := config.DB.Save(&product).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	updated, err := updateProduct(ctx, product.ID, updateData)
+	if err != nil {
+		return errorResponse(c, err)
 	}
 
-	return c.JSON(http.StatusOK, product)
+	return c.JSON(http.StatusOK, updated)
 }
 
 func DeleteProduct(c echo.Context) error {
-	id := c.Param("id")
+	ctx := c.Request().Context()
+	if err := deleteProduct(ctx, c.Param("id")); err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Product deleted"})
+}
+
+// updateProduct overwrites the editable fields of a product and notifies
+// wishlists when its price went down.
+func updateProduct(ctx context.Context, id interface{}, data *models.Product) (*models.Product, error) {
 	var product models.Product
+	if err := requestDB(ctx).First(&product, id).Error; err != nil {
+		return nil, errProductNotFound
+	}
 
-	if err := config.DB.First(&product, id).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"message": "Product not found"})
+	oldPrice := product.Price
+	product.Name = data.Name
+	product.Description = data.Description
+	product.Price = data.Price
+	product.CategoryID = data.CategoryID
+	product.TaxClassID = data.TaxClassID
+	product.Weight = data.Weight
+	product.Length = data.Length
+	product.Width = data.Width
+	product.Height = data.Height
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.ProductUpdated, product)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := config.DB.Delete(&product).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	invalidateCatalog()
+	notifyPriceDrop(ctx, product, oldPrice)
+
+	return &product, nil
+}
+
+// createProduct stores a new product and drops the cached catalogue.
+func createProduct(ctx context.Context, product *models.Product) error {
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.ProductCreated, product)
+	})
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "Product deleted"})
+	invalidateCatalog()
+	return nil
+}
+
+func deleteProduct(ctx context.Context, id interface{}) error {
+	var product models.Product
+	if err := requestDB(ctx).First(&product, id).Error; err != nil {
+		return errProductNotFound
+	}
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, webhooks.ProductDeleted, product)
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateCatalog()
+	return nil
 }
 
 func ScopeMinPrice(price float64) func(db *gorm.DB) *gorm.DB {
@@ -91,6 +171,7 @@ func ScopeMinPrice(price float64) func(db *gorm.DB) *gorm.DB {
 }
 
 func GetProductsWithScopes(c echo.Context) error {
+	ctx := c.Request().Context()
 	priceStr := c.QueryParam("min_price")
 	if priceStr == "" {
 		priceStr = "0"
@@ -98,8 +179,13 @@ func GetProductsWithScopes(c echo.Context) error {
 	minPrice, _ := strconv.ParseFloat(priceStr, 64)
 
 	var products []models.Product
-	if err := config.DB.Scopes(ScopeMinPrice(minPrice)).Find(&products).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+	if err := requestDB(ctx).Preload("Category").Scopes(ScopeMinPrice(minPrice)).Find(&products).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	products, err := withTaxPrices(c, products)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
 	}
 
 	return c.JSON(http.StatusOK, products)
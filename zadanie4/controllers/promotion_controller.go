@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type promotionRequest struct {
+	models.Promotion
+	ProductIDs []uint `json:"product_ids"`
+}
+
+func validatePromotion(promotion *models.Promotion) i18n.Code {
+	if promotion.Name == "" {
+		return i18n.CodeNameRequired
+	}
+	switch promotion.Type {
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQuantity < 1 || promotion.FreeQuantity < 1 {
+			return i18n.CodePromotionInvalidQuantity
+		}
+	case models.PromotionTypeCategoryPercentage:
+		if promotion.Percentage <= 0 || promotion.Percentage > 100 {
+			return i18n.CodePromotionInvalidPercent
+		}
+		if promotion.MinAmount < 0 {
+			return i18n.CodePromotionNegativeAmount
+		}
+	case models.PromotionTypeBundle:
+		if len(promotion.Products) < 2 {
+			return i18n.CodePromotionBundleTooSmall
+		}
+		if promotion.BundlePrice < 0 {
+			return i18n.CodePromotionNegativeAmount
+		}
+	default:
+		return i18n.CodePromotionInvalidType
+	}
+	if promotion.ValidFrom != nil && promotion.ValidUntil != nil && promotion.ValidUntil.Before(*promotion.ValidFrom) {
+		return i18n.CodeInvalidPeriod
+	}
+	return ""
+}
+
+// bindPromotion reads a promotion from the request, resolving product_ids.
+func bindPromotion(c echo.Context) (*models.Promotion, int, i18n.Code) {
+	ctx := c.Request().Context()
+	req := new(promotionRequest)
+	if err := c.Bind(req); err != nil {
+		logging.FromContext(ctx).Warn("request failed", "error", err)
+		return nil, http.StatusBadRequest, i18n.CodeInvalidRequest
+	}
+
+	promotion := req.Promotion
+	promotion.Model = gorm.Model{}
+	promotion.Products = nil
+	if len(req.ProductIDs) > 0 {
+		if err := requestDB(ctx).Find(&promotion.Products, req.ProductIDs).Error; err != nil {
+			logging.FromContext(ctx).Error("request failed", "error", err)
+			return nil, http.StatusInternalServerError, i18n.CodeInternal
+		}
+		if len(promotion.Products) != len(req.ProductIDs) {
+			return nil, http.StatusBadRequest, i18n.CodeProductNotFound
+		}
+	}
+
+	if code := validatePromotion(&promotion); code != "" {
+		return nil, http.StatusBadRequest, code
+	}
+
+	return &promotion, 0, ""
+}
+
+func CreatePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	promotion, status, code := bindPromotion(c)
+	if promotion == nil {
+		return errorJSON(c, status, code)
+	}
+
+	if err := requestDB(ctx).Create(promotion).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, promotion)
+}
+
+// GetPromotions lists the promotions that apply now. Callers who manage
+// promotions also see inactive, scheduled and expired ones.
+func GetPromotions(c echo.Context) error {
+	ctx := c.Request().Context()
+	manage := auth.FromContext(ctx).Can(auth.PermPromotionsManage)
+	query := requestDB(ctx).Preload("Products").Order("priority DESC")
+	if !manage {
+		query = query.Where("active = ?", true)
+	}
+
+	promotions := []models.Promotion{}
+	if err := query.Find(&promotions).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if !manage {
+		now := time.Now()
+		current := promotions[:0]
+		for _, promotion := range promotions {
+			if promotion.IsActive(now) {
+				current = append(current, promotion)
+			}
+		}
+		promotions = current
+	}
+
+	return c.JSON(http.StatusOK, promotions)
+}
+
+func UpdatePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	var existing models.Promotion
+	if err := requestDB(ctx).First(&existing, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodePromotionNotFound)
+	}
+
+	promotion, status, code := bindPromotion(c)
+	if promotion == nil {
+		return errorJSON(c, status, code)
+	}
+	promotion.Model = existing.Model
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Products").Save(promotion).Error; err != nil {
+			return err
+		}
+		return tx.Model(promotion).Association("Products").Replace(promotion.Products)
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, promotion)
+}
+
+func DeletePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	var promotion models.Promotion
+
+	if err := requestDB(ctx).First(&promotion, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodePromotionNotFound)
+	}
+
+	if err := requestDB(ctx).Delete(&promotion).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Promotion deleted"})
+}
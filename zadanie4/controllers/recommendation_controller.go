@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"shop/i18n"
+	"shop/recommendations"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultRecommendations = 5
+	maxRecommendations     = 20
+)
+
+// GetProductRecommendations lists products frequently bought together with
+// a product, falling back to products from the same category.
+func GetProductRecommendations(c echo.Context) error {
+	ctx := c.Request().Context()
+	id, err := parseProductID(c.Param("id"))
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	product, err := findProduct(ctx, id)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultRecommendations
+	}
+	if limit > maxRecommendations {
+		limit = maxRecommendations
+	}
+
+	result, err := recommendations.For(requestDB(ctx), product, limit)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"shop/auth"
+	"shop/i18n"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPerPage = 10
+	maxPerPage     = 100
+)
+
+// reviewRequest is the body of a new or edited review; its author is the
+// signed-in user.
+type reviewRequest struct {
+	Rating  int    `json:"rating"`
+	Title   string `json:"title"`
+	Comment string `json:"comment"`
+}
+
+type moderationRequest struct {
+	Status string `json:"status"`
+}
+
+type reviewPage struct {
+	Reviews []models.Review `json:"reviews"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"per_page"`
+	Total   int64           `json:"total"`
+}
+
+type ratingSummary struct {
+	ProductID     uint
+	AverageRating float64
+	ReviewCount   int64
+}
+
+func ScopeApprovedReviews(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.ReviewStatusApproved)
+}
+
+func ScopeReviewStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
+
+func ScopeReviewOrder(sort string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sort == "helpful" {
+			return db.Order("helpful_count DESC").Order("created_at DESC")
+		}
+		return db.Order("created_at DESC")
+	}
+}
+
+func ScopePaginate(page, perPage int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset((page - 1) * perPage).Limit(perPage)
+	}
+}
+
+func paginationParams(c echo.Context) (int, int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.QueryParam("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	return page, perPage
+}
+
+func validateReview(req *reviewRequest) i18n.Code {
+	if req.Rating < 1 || req.Rating > 5 {
+		return i18n.CodeRatingOutOfRange
+	}
+	return ""
+}
+
+func CreateReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	var product models.Product
+	if err := requestDB(ctx).First(&product, productID).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotFound)
+	}
+
+	req := new(reviewRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if code := validateReview(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	review := models.Review{
+		ProductID: product.ID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Title:     req.Title,
+		Comment:   req.Comment,
+		Status:    models.ReviewStatusPending,
+	}
+
+	if err := requestDB(ctx).Create(&review).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, review)
+}
+
+func GetProductReviews(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("id")
+
+	var product models.Product
+	if err := requestDB(ctx).First(&product, productID).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotFound)
+	}
+
+	page, perPage := paginationParams(c)
+	query := requestDB(ctx).Model(&models.Review{}).
+		Where("product_id = ?", product.ID).
+		Scopes(ScopeApprovedReviews)
+
+	return listReviews(c, query, page, perPage)
+}
+
+func GetReviews(c echo.Context) error {
+	ctx := c.Request().Context()
+	status := c.QueryParam("status")
+	if status == "" {
+		status = models.ReviewStatusPending
+	}
+	if !models.IsValidReviewStatus(status) {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidReviewStatus)
+	}
+
+	page, perPage := paginationParams(c)
+	query := requestDB(ctx).Model(&models.Review{}).Scopes(ScopeReviewStatus(status))
+
+	return listReviews(c, query, page, perPage)
+}
+
+func listReviews(c echo.Context, query *gorm.DB, page, perPage int) error {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	reviews := []models.Review{}
+	err := query.
+		Scopes(ScopeReviewOrder(c.QueryParam("sort")), ScopePaginate(page, perPage)).
+		Find(&reviews).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, reviewPage{
+		Reviews: reviews,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	})
+}
+
+// authorizeReview lets the review's author and moderators change it.
+func authorizeReview(ctx context.Context, review models.Review) error {
+	principal := auth.FromContext(ctx)
+	if principal == nil {
+		return auth.ErrUnauthenticated
+	}
+	if principal.Can(auth.PermReviewsModerate) || (principal.UserID != 0 && principal.UserID == review.UserID) {
+		return nil
+	}
+	return errReviewOtherUser
+}
+
+func UpdateReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	if auth.FromContext(ctx) == nil {
+		return errorResponse(c, auth.ErrUnauthenticated)
+	}
+
+	var review models.Review
+	if err := requestDB(ctx).First(&review, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeReviewNotFound)
+	}
+	if err := authorizeReview(ctx, review); err != nil {
+		return errorResponse(c, err)
+	}
+
+	req := new(reviewRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if code := validateReview(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	// Edited reviews go back to the moderation queue.
+	review.Rating = req.Rating
+	review.Title = req.Title
+	review.Comment = req.Comment
+	review.Status = models.ReviewStatusPending
+
+	if err := requestDB(ctx).Save(&review).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	// Cached products carry rating aggregates of approved reviews.
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, review)
+}
+
+// MarkReviewHelpful records the caller's helpful vote on an approved review.
+// Every user votes once per review and not on their own reviews, so the
+// helpful ranking cannot be stuffed.
+func MarkReviewHelpful(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	var review models.Review
+	if err := requestDB(ctx).Scopes(ScopeApprovedReviews).First(&review, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeReviewNotFound)
+	}
+	if review.UserID == userID {
+		return errorJSON(c, http.StatusForbidden, i18n.CodeReviewOwnVote)
+	}
+
+	voted := false
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		vote := models.ReviewVote{ReviewID: review.ID, UserID: userID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		voted = true
+		return tx.Model(&review).UpdateColumn("helpful_count", gorm.Expr("helpful_count + ?", 1)).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if !voted {
+		return errorJSON(c, http.StatusConflict, i18n.CodeReviewAlreadyVoted)
+	}
+
+	if err := requestDB(ctx).First(&review, review.ID).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, review)
+}
+
+func ModerateReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	var review models.Review
+	if err := requestDB(ctx).First(&review, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeReviewNotFound)
+	}
+
+	req := new(moderationRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if !models.IsValidReviewStatus(req.Status) {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidReviewStatus)
+	}
+
+	review.Status = req.Status
+	if err := requestDB(ctx).Save(&review).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, review)
+}
+
+func DeleteReview(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	if auth.FromContext(ctx) == nil {
+		return errorResponse(c, auth.ErrUnauthenticated)
+	}
+
+	var review models.Review
+	if err := requestDB(ctx).First(&review, id).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeReviewNotFound)
+	}
+	if err := authorizeReview(ctx, review); err != nil {
+		return errorResponse(c, err)
+	}
+
+	if err := requestDB(ctx).Delete(&review).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Review deleted"})
+}
+
+// attachRatings fills AverageRating and ReviewCount from approved reviews.
+func attachRatings(ctx context.Context, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	var summaries []ratingSummary
+	err := requestDB(ctx).Model(&models.Review{}).
+		Select("product_id, AVG(rating) AS average_rating, COUNT(*) AS review_count").
+		Scopes(ScopeApprovedReviews).
+		Where("product_id IN ?", ids).
+		Group("product_id").
+		Scan(&summaries).Error
+	if err != nil {
+		return err
+	}
+
+	byProduct := make(map[uint]ratingSummary, len(summaries))
+	for _, summary := range summaries {
+		byProduct[summary.ProductID] = summary
+	}
+
+	for i := range products {
+		summary := byProduct[products[i].ID]
+		products[i].AverageRating = summary.AverageRating
+		products[i].ReviewCount = summary.ReviewCount
+	}
+
+	return nil
+}
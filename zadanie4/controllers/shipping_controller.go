@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"shop/i18n"
+	"shop/models"
+	"shop/shipping"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errShippingMethodNotFound = errors.New("shipping method not found")
+	errShippingUnavailable    = errors.New("shipping method not available for the cart")
+
+	shippingCodePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	shippingKinds       = []string{models.ShippingCourier, models.ShippingParcelLocker, models.ShippingPickup}
+)
+
+type shippingMethodRequest struct {
+	Code      string                `json:"code"`
+	Name      string                `json:"name"`
+	Kind      string                `json:"kind"`
+	Active    *bool                 `json:"active"`
+	MaxLength float64               `json:"max_length"`
+	MaxWidth  float64               `json:"max_width"`
+	MaxHeight float64               `json:"max_height"`
+	Rules     []models.ShippingRule `json:"rules"`
+}
+
+type cartShippingRequest struct {
+	ShippingMethodID *uint `json:"shipping_method_id"`
+}
+
+// bindShippingMethod validates the request body and copies it onto method.
+// Methods are active unless the body says otherwise.
+func bindShippingMethod(c echo.Context, method *models.ShippingMethod) i18n.Code {
+	req := new(shippingMethodRequest)
+	if err := c.Bind(req); err != nil {
+		return i18n.CodeInvalidRequest
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+	req.Name = strings.TrimSpace(req.Name)
+	if !shippingCodePattern.MatchString(req.Code) {
+		return i18n.CodeInvalidShippingCode
+	}
+	if req.Name == "" {
+		return i18n.CodeNameRequired
+	}
+	if !isShippingKind(req.Kind) {
+		return i18n.CodeInvalidShippingKind
+	}
+	if req.MaxLength < 0 || req.MaxWidth < 0 || req.MaxHeight < 0 {
+		return i18n.CodeInvalidShippingRule
+	}
+
+	method.Code = req.Code
+	method.Name = req.Name
+	method.Kind = req.Kind
+	method.Active = req.Active == nil || *req.Active
+	method.MaxLength, method.MaxWidth, method.MaxHeight = req.MaxLength, req.MaxWidth, req.MaxHeight
+	method.Rules = make([]models.ShippingRule, len(req.Rules))
+	for i, rule := range req.Rules {
+		rule.Region = strings.ToUpper(strings.TrimSpace(rule.Region))
+		if !validShippingRule(rule) {
+			return i18n.CodeInvalidShippingRule
+		}
+		method.Rules[i] = models.ShippingRule{
+			Region:    rule.Region,
+			MinWeight: rule.MinWeight,
+			MaxWeight: rule.MaxWeight,
+			MinValue:  rule.MinValue,
+			MaxValue:  rule.MaxValue,
+			Price:     rule.Price,
+			FreeAbove: rule.FreeAbove,
+		}
+	}
+	return ""
+}
+
+func isShippingKind(kind string) bool {
+	for _, k := range shippingKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func validShippingRule(rule models.ShippingRule) bool {
+	if rule.Region != "" && !regionPattern.MatchString(rule.Region) {
+		return false
+	}
+	for _, amount := range []float64{rule.MinWeight, rule.MaxWeight, rule.MinValue, rule.MaxValue, rule.Price, rule.FreeAbove} {
+		if amount < 0 {
+			return false
+		}
+	}
+	return (rule.MaxWeight == 0 || rule.MaxWeight >= rule.MinWeight) &&
+		(rule.MaxValue == 0 || rule.MaxValue >= rule.MinValue)
+}
+
+// shippingCodeTaken reports whether another method of the store uses code.
+func shippingCodeTaken(ctx context.Context, code string, id uint) (bool, error) {
+	var count int64
+	err := requestDB(ctx).Model(&models.ShippingMethod{}).
+		Where("code = ? AND id <> ?", code, id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func GetShippingMethods(c echo.Context) error {
+	ctx := c.Request().Context()
+	methods := []models.ShippingMethod{}
+	if err := requestDB(ctx).Preload("Rules").Order("id").Find(&methods).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, methods)
+}
+
+func CreateShippingMethod(c echo.Context) error {
+	ctx := c.Request().Context()
+	method := models.ShippingMethod{}
+	if code := bindShippingMethod(c, &method); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	taken, err := shippingCodeTaken(ctx, method.Code, 0)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if taken {
+		return errorJSON(c, http.StatusConflict, i18n.CodeShippingMethodCodeTaken)
+	}
+
+	if err := requestDB(ctx).Create(&method).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, method)
+}
+
+// UpdateShippingMethod replaces a shipping method and its rules. Placed
+// orders keep the shipping cost they were charged.
+func UpdateShippingMethod(c echo.Context) error {
+	ctx := c.Request().Context()
+	var method models.ShippingMethod
+	if err := requestDB(ctx).First(&method, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeShippingMethodNotFound)
+	}
+
+	if code := bindShippingMethod(c, &method); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	taken, err := shippingCodeTaken(ctx, method.Code, method.ID)
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if taken {
+		return errorJSON(c, http.StatusConflict, i18n.CodeShippingMethodCodeTaken)
+	}
+
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("shipping_method_id = ?", method.ID).Delete(&models.ShippingRule{}).Error; err != nil {
+			return err
+		}
+		for i := range method.Rules {
+			method.Rules[i].ShippingMethodID = method.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&method).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, method)
+}
+
+// DeleteShippingMethod removes a method; carts that chose it ship with
+// nothing until another one is picked.
+func DeleteShippingMethod(c echo.Context) error {
+	ctx := c.Request().Context()
+	var method models.ShippingMethod
+	if err := requestDB(ctx).First(&method, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeShippingMethodNotFound)
+	}
+
+	err := requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Cart{}).Where("shipping_method_id = ?", method.ID).
+			UpdateColumn("shipping_method_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&method).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Shipping method deleted"})
+}
+
+// GetCartShippingQuotes lists the shipping methods available for a cart,
+// cheapest first, priced for its region or ?region.
+func GetCartShippingQuotes(c echo.Context) error {
+	region := ""
+	if c.QueryParam("region") != "" {
+		region = requestRegion(c)
+	}
+
+	quotes, err := cartShippingQuotes(c.Request().Context(), c.Param("id"), region)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, quotes)
+}
+
+// cartShippingQuotes prices the active shipping methods for a cart in
+// region, or in the cart's own region when it is empty.
+func cartShippingQuotes(ctx context.Context, cartID interface{}, region string) ([]models.ShippingQuote, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, cartID); err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = cart.Region
+	}
+
+	var methods []models.ShippingMethod
+	if err := requestDB(ctx).Preload("Rules").Where("active = ?", true).Find(&methods).Error; err != nil {
+		return nil, err
+	}
+
+	return shipping.Quotes(methods, cart.Units(), cartValue(cart), region), nil
+}
+
+func SetCartShippingMethod(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(cartShippingRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	cart, err := setCartShippingMethod(ctx, c.Param("id"), req.ShippingMethodID)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// setCartShippingMethod picks the shipping method of a cart, which must be
+// available for it; nil clears it.
+func setCartShippingMethod(ctx context.Context, cartID interface{}, methodID *uint) (*models.Cart, error) {
+	var cart models.Cart
+	if err := loadCart(ctx, &cart, cartID); err != nil {
+		return nil, err
+	}
+
+	checkedOut, err := isCheckedOut(ctx, cart.ID)
+	if err != nil {
+		return nil, err
+	}
+	if checkedOut {
+		return nil, errCartCheckedOut
+	}
+
+	cart.ShippingMethod = nil
+	if methodID != nil {
+		var method models.ShippingMethod
+		if err := requestDB(ctx).Preload("Rules").First(&method, *methodID).Error; err != nil {
+			return nil, errShippingMethodNotFound
+		}
+		if _, ok := shipping.Quote(method, cart.Units(), cartValue(cart), cart.Region); !ok {
+			return nil, errShippingUnavailable
+		}
+		cart.ShippingMethod = &method
+	}
+
+	if err := requestDB(ctx).Model(&cart).UpdateColumn("shipping_method_id", methodID).Error; err != nil {
+		return nil, err
+	}
+	cart.ShippingMethodID = methodID
+	if err := applyCartTotals(ctx, &cart); err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
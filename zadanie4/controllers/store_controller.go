@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+	"shop/tenant"
+
+	"github.com/labstack/echo/v4"
+)
+
+var storeSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// defaultStoreSlug is the store serving hosts without a store of their own.
+var defaultStoreSlug = tenant.DefaultSlug
+
+type storeRequest struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+}
+
+// ConfigureStores sets the slug of the fallback store, which cannot be
+// deleted.
+func ConfigureStores(defaultSlug string) {
+	defaultStoreSlug = defaultSlug
+}
+
+// DefaultStoreOnly limits a route to requests for the default store. Staff
+// are bound to their store, so store management is left to the admins of
+// the default store rather than those of any storefront.
+func DefaultStoreOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if store, _ := tenant.FromContext(c.Request().Context()); store.Slug != defaultStoreSlug {
+			return errorJSON(c, http.StatusForbidden, i18n.CodeForbidden)
+		}
+		return next(c)
+	}
+}
+
+// bindStore validates the request body and copies it onto store. It
+// returns a status and code to respond with when the body is not valid.
+func bindStore(c echo.Context, store *models.Store) (int, i18n.Code) {
+	ctx := c.Request().Context()
+	req := new(storeRequest)
+	if err := c.Bind(req); err != nil {
+		logging.FromContext(ctx).Warn("request failed", "error", err)
+		return http.StatusBadRequest, i18n.CodeInvalidRequest
+	}
+
+	req.Slug = strings.TrimSpace(req.Slug)
+	req.Name = strings.TrimSpace(req.Name)
+	if !storeSlugPattern.MatchString(req.Slug) {
+		return http.StatusBadRequest, i18n.CodeInvalidStoreSlug
+	}
+	if req.Name == "" {
+		return http.StatusBadRequest, i18n.CodeNameRequired
+	}
+
+	var count int64
+	err := requestDB(ctx).Model(&models.Store{}).
+		Where("slug = ? AND id <> ?", req.Slug, store.ID).
+		Count(&count).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("request failed", "error", err)
+		return http.StatusInternalServerError, i18n.CodeInternal
+	}
+	if count > 0 {
+		return http.StatusConflict, i18n.CodeStoreSlugTaken
+	}
+
+	store.Slug = req.Slug
+	store.Name = req.Name
+	store.Domain = strings.ToLower(strings.TrimSpace(req.Domain))
+	return 0, ""
+}
+
+// CreateStore adds a storefront. It starts with an empty catalogue.
+func CreateStore(c echo.Context) error {
+	ctx := c.Request().Context()
+	store := models.Store{}
+	if status, code := bindStore(c, &store); code != "" {
+		return errorJSON(c, status, code)
+	}
+
+	if err := requestDB(ctx).Create(&store).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	tenant.Invalidate()
+
+	return c.JSON(http.StatusCreated, store)
+}
+
+func GetStores(c echo.Context) error {
+	ctx := c.Request().Context()
+	stores := []models.Store{}
+	if err := requestDB(ctx).Order("id").Find(&stores).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, stores)
+}
+
+// UpdateStore changes a store's slug, name or domain. Clients still using
+// the old domain are served by the default store afterwards, whose slug
+// cannot change.
+func UpdateStore(c echo.Context) error {
+	ctx := c.Request().Context()
+	var store models.Store
+	if err := requestDB(ctx).First(&store, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeStoreNotFound)
+	}
+
+	wasDefault := store.Slug == defaultStoreSlug
+	if status, code := bindStore(c, &store); code != "" {
+		return errorJSON(c, status, code)
+	}
+	if wasDefault && store.Slug != defaultStoreSlug {
+		return errorJSON(c, http.StatusConflict, i18n.CodeStoreIsDefault)
+	}
+	if err := requestDB(ctx).Save(&store).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	tenant.Invalidate()
+
+	return c.JSON(http.StatusOK, store)
+}
+
+// DeleteStore takes a storefront offline. Its products, categories, carts
+// and orders are kept but no longer reachable.
+func DeleteStore(c echo.Context) error {
+	ctx := c.Request().Context()
+	var store models.Store
+	if err := requestDB(ctx).First(&store, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeStoreNotFound)
+	}
+	if store.Slug == defaultStoreSlug {
+		return errorJSON(c, http.StatusConflict, i18n.CodeStoreIsDefault)
+	}
+
+	if err := requestDB(ctx).Delete(&store).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	tenant.Invalidate()
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Store deleted"})
+}
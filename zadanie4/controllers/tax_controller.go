@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"shop/i18n"
+	"shop/models"
+	"shop/tax"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// RegionHeader selects the region product prices are taxed for.
+const RegionHeader = "X-Region"
+
+var (
+	taxClassCodePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	regionPattern       = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+var taxConfig = tax.Config{Region: "PL", PricesIncludeTax: true, DisplayMode: tax.ModeGross}
+
+type taxClassRequest struct {
+	Code  string           `json:"code"`
+	Name  string           `json:"name"`
+	Rates []models.TaxRate `json:"rates"`
+}
+
+type categoryTaxClassRequest struct {
+	TaxClassID *uint `json:"tax_class_id"`
+}
+
+// ConfigureTax sets the default region, whether catalogue prices include
+// tax and the default price display mode.
+func ConfigureTax(config tax.Config) {
+	config.Region = strings.ToUpper(config.Region)
+	taxConfig = config
+}
+
+func loadTaxTable(ctx context.Context) (*tax.Table, error) {
+	return tax.Load(requestDB(ctx), taxConfig)
+}
+
+// requestRegion is the region named by ?region or the X-Region header, or
+// the default one.
+func requestRegion(c echo.Context) string {
+	region := c.QueryParam("region")
+	if region == "" {
+		region = c.Request().Header.Get(RegionHeader)
+	}
+	if region = strings.ToUpper(strings.TrimSpace(region)); regionPattern.MatchString(region) {
+		return region
+	}
+	return taxConfig.Region
+}
+
+// withTaxPrices returns a copy of products with prices for the request's
+// region in the display mode chosen by ?prices=net|gross. The products may
+// come from the catalogue cache, so they are not modified.
+func withTaxPrices(c echo.Context, products []models.Product) ([]models.Product, error) {
+	table, err := loadTaxTable(c.Request().Context())
+	if err != nil {
+		return nil, err
+	}
+
+	priced := append([]models.Product(nil), products...)
+	table.ApplyPrices(priced, requestRegion(c), c.QueryParam("prices"))
+	return priced, nil
+}
+
+// bindTaxClass validates the request body and copies it onto class.
+func bindTaxClass(c echo.Context, class *models.TaxClass) (int, i18n.Code) {
+	req := new(taxClassRequest)
+	if err := c.Bind(req); err != nil {
+		return http.StatusBadRequest, i18n.CodeInvalidRequest
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+	req.Name = strings.TrimSpace(req.Name)
+	if !taxClassCodePattern.MatchString(req.Code) {
+		return http.StatusBadRequest, i18n.CodeInvalidTaxClassCode
+	}
+	if req.Name == "" {
+		return http.StatusBadRequest, i18n.CodeNameRequired
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Rates {
+		rate := &req.Rates[i]
+		rate.Region = strings.ToUpper(strings.TrimSpace(rate.Region))
+		if !regionPattern.MatchString(rate.Region) || seen[rate.Region] || rate.Rate < 0 || rate.Rate > 100 {
+			return http.StatusBadRequest, i18n.CodeInvalidTaxRate
+		}
+		seen[rate.Region] = true
+	}
+
+	class.Code = req.Code
+	class.Name = req.Name
+	class.Rates = make([]models.TaxRate, len(req.Rates))
+	for i, rate := range req.Rates {
+		class.Rates[i] = models.TaxRate{Region: rate.Region, Rate: rate.Rate}
+	}
+	return 0, ""
+}
+
+func GetTaxClasses(c echo.Context) error {
+	ctx := c.Request().Context()
+	classes := []models.TaxClass{}
+	if err := requestDB(ctx).Preload("Rates").Order("id").Find(&classes).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, classes)
+}
+
+func CreateTaxClass(c echo.Context) error {
+	ctx := c.Request().Context()
+	class := models.TaxClass{}
+	if status, code := bindTaxClass(c, &class); code != "" {
+		return errorJSON(c, status, code)
+	}
+
+	var count int64
+	if err := requestDB(ctx).Model(&models.TaxClass{}).Where("code = ?", class.Code).Count(&count).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeTaxClassCodeTaken)
+	}
+
+	if err := requestDB(ctx).Create(&class).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	invalidateCatalog()
+
+	return c.JSON(http.StatusCreated, class)
+}
+
+// UpdateTaxClass renames a tax class and replaces its rates. Placed orders
+// keep the tax they were charged.
+func UpdateTaxClass(c echo.Context) error {
+	ctx := c.Request().Context()
+	var class models.TaxClass
+	if err := requestDB(ctx).First(&class, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeTaxClassNotFound)
+	}
+
+	if status, code := bindTaxClass(c, &class); code != "" {
+		return errorJSON(c, status, code)
+	}
+
+	var count int64
+	err := requestDB(ctx).Model(&models.TaxClass{}).
+		Where("code = ? AND id <> ?", class.Code, class.ID).
+		Count(&count).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeTaxClassCodeTaken)
+	}
+
+	err = requestDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("tax_class_id = ?", class.ID).Delete(&models.TaxRate{}).Error; err != nil {
+			return err
+		}
+		for i := range class.Rates {
+			class.Rates[i].TaxClassID = class.ID
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&class).Error
+	})
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, class)
+}
+
+// UpdateCategoryTaxClass sets the tax class of a category's products that
+// have none of their own. A null tax_class_id clears it.
+func UpdateCategoryTaxClass(c echo.Context) error {
+	ctx := c.Request().Context()
+	var category models.Category
+	if err := requestDB(ctx).First(&category, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeCategoryNotFound)
+	}
+
+	req := new(categoryTaxClassRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if req.TaxClassID != nil {
+		err := requestDB(ctx).First(&models.TaxClass{}, *req.TaxClassID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errorJSON(c, http.StatusUnprocessableEntity, i18n.CodeTaxClassNotFound)
+		}
+		if err != nil {
+			return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+		}
+	}
+
+	if err := requestDB(ctx).Model(&category).UpdateColumn("tax_class_id", req.TaxClassID).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	category.TaxClassID = req.TaxClassID
+	invalidateCatalog()
+
+	return c.JSON(http.StatusOK, category)
+}
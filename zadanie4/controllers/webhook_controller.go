@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"shop/i18n"
+	"shop/models"
+	"shop/webhooks"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret signs deliveries; one is generated when left empty on create.
+	// Setting it on update rotates the secret.
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
+}
+
+type webhookDeliveryPage struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Page       int                      `json:"page"`
+	PerPage    int                      `json:"per_page"`
+	Total      int64                    `json:"total"`
+}
+
+type webhookEventTypes struct {
+	Events []string `json:"events"`
+}
+
+func validateWebhook(req *webhookRequest) i18n.Code {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return i18n.CodeWebhookInvalidURL
+	}
+	if len(req.Events) == 0 {
+		return i18n.CodeWebhookInvalidEvents
+	}
+	for _, event := range req.Events {
+		if !webhooks.ValidEventType(event) {
+			return i18n.CodeWebhookInvalidEvents
+		}
+	}
+	return ""
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// CreateWebhook subscribes a URL to events. The response is the only place
+// the signing secret is shown.
+func CreateWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := new(webhookRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if code := validateWebhook(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	subscription := models.WebhookSubscription{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Active: req.Active == nil || *req.Active,
+	}
+	if subscription.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+		}
+		subscription.Secret = secret
+	}
+
+	if err := requestDB(ctx).Create(&subscription).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, subscription)
+}
+
+func GetWebhooks(c echo.Context) error {
+	ctx := c.Request().Context()
+	subscriptions := []models.WebhookSubscription{}
+	if err := requestDB(ctx).Order("id").Find(&subscriptions).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+func GetWebhookByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	var subscription models.WebhookSubscription
+	if err := requestDB(ctx).First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWebhookNotFound)
+	}
+
+	subscription.Secret = ""
+	return c.JSON(http.StatusOK, subscription)
+}
+
+func UpdateWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	var subscription models.WebhookSubscription
+	if err := requestDB(ctx).First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWebhookNotFound)
+	}
+
+	req := new(webhookRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if code := validateWebhook(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	subscription.URL = req.URL
+	subscription.Events = req.Events
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := requestDB(ctx).Save(&subscription).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	subscription.Secret = ""
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteWebhook removes a subscription; its pending deliveries are marked
+// failed by the dispatcher.
+func DeleteWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	var subscription models.WebhookSubscription
+	if err := requestDB(ctx).First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWebhookNotFound)
+	}
+
+	if err := requestDB(ctx).Delete(&subscription).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Webhook deleted"})
+}
+
+func GetWebhookEventTypes(c echo.Context) error {
+	return c.JSON(http.StatusOK, webhookEventTypes{Events: webhooks.EventTypes})
+}
+
+// GetWebhookDeliveries is the delivery log of a subscription, newest first,
+// with every attempt made. ?status= narrows it to pending, succeeded or
+// failed deliveries.
+func GetWebhookDeliveries(c echo.Context) error {
+	ctx := c.Request().Context()
+	var subscription models.WebhookSubscription
+	if err := requestDB(ctx).First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWebhookNotFound)
+	}
+
+	query := requestDB(ctx).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscription.ID)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+		query = query.Where("status = ?", status)
+	default:
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeInvalidDeliveryStatus)
+	}
+
+	page, perPage := paginationParams(c)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err := query.
+		Preload("Event").
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("id DESC").
+		Scopes(ScopePaginate(page, perPage)).
+		Find(&deliveries).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, webhookDeliveryPage{
+		Deliveries: deliveries,
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+	})
+}
+
+// RedeliverWebhook queues a delivery to be sent again with a fresh attempt
+// budget, e.g. after the receiver was fixed.
+func RedeliverWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	var subscription models.WebhookSubscription
+	if err := requestDB(ctx).First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWebhookNotFound)
+	}
+
+	var delivery models.WebhookDelivery
+	err := requestDB(ctx).
+		Preload("Event").
+		Where("subscription_id = ?", subscription.ID).
+		First(&delivery, c.Param("delivery_id")).Error
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeDeliveryNotFound)
+	}
+
+	if err := webhooks.Redeliver(requestDB(ctx), &delivery); err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+type wishlistRequest struct {
+	Name string `json:"name"`
+}
+
+type moveToCartRequest struct {
+	CartID uint `json:"cart_id"`
+}
+
+// PriceDrop is passed to price drop hooks for every wishlist holding a
+// product whose price was lowered.
+type PriceDrop struct {
+	WishlistID     uint    `json:"wishlist_id"`
+	UserID         uint    `json:"user_id"`
+	ProductID      uint    `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	PriceWhenAdded float64 `json:"price_when_added"`
+	OldPrice       float64 `json:"old_price"`
+	NewPrice       float64 `json:"new_price"`
+}
+
+type PriceDropHook func(drop PriceDrop)
+
+var priceDropHooks []PriceDropHook
+
+// RegisterPriceDropHook adds a hook called after a wishlisted product gets
+// cheaper. Hooks should be registered before the server starts.
+func RegisterPriceDropHook(hook PriceDropHook) {
+	priceDropHooks = append(priceDropHooks, hook)
+}
+
+// findWishlist loads a wishlist of the user. Other users' wishlists are
+// not found.
+func findWishlist(ctx context.Context, userID uint, id interface{}) (models.Wishlist, error) {
+	var wishlist models.Wishlist
+	err := requestDB(ctx).Where("user_id = ?", userID).First(&wishlist, id).Error
+	return wishlist, err
+}
+
+// CreateWishlist adds a named wishlist for the signed-in caller.
+func CreateWishlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	req := new(wishlistRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+	if req.Name == "" {
+		return errorJSON(c, http.StatusBadRequest, i18n.CodeNameRequired)
+	}
+	wishlist := &models.Wishlist{UserID: userID, Name: req.Name}
+
+	var count int64
+	err = requestDB(ctx).Model(&models.Wishlist{}).
+		Where("user_id = ? AND name = ?", wishlist.UserID, wishlist.Name).
+		Count(&count).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeWishlistAlreadyExists)
+	}
+
+	if err := requestDB(ctx).Create(wishlist).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusCreated, wishlist)
+}
+
+// GetWishlists lists the caller's wishlists.
+func GetWishlists(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	wishlists := []models.Wishlist{}
+	err = requestDB(ctx).Preload("Items.Product").
+		Where("user_id = ?", userID).
+		Order("name").
+		Find(&wishlists).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, wishlists)
+}
+
+func GetWishlistByID(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	var wishlist models.Wishlist
+	err = requestDB(ctx).Preload("Items.Product").Where("user_id = ?", userID).First(&wishlist, c.Param("id")).Error
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWishlistNotFound)
+	}
+
+	return c.JSON(http.StatusOK, wishlist)
+}
+
+func DeleteWishlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	wishlist, err := findWishlist(ctx, userID, c.Param("id"))
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWishlistNotFound)
+	}
+
+	if err := requestDB(ctx).Select("Items").Delete(&wishlist).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Wishlist deleted"})
+}
+
+func AddProductToWishlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("product_id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	wishlist, err := findWishlist(ctx, userID, c.Param("wishlist_id"))
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWishlistNotFound)
+	}
+
+	var product models.Product
+	if err := requestDB(ctx).First(&product, productID).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotFound)
+	}
+
+	var count int64
+	err = requestDB(ctx).Model(&models.WishlistItem{}).
+		Where("wishlist_id = ? AND product_id = ?", wishlist.ID, product.ID).
+		Count(&count).Error
+	if err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, i18n.CodeProductAlreadyInList)
+	}
+
+	item := models.WishlistItem{
+		WishlistID:     wishlist.ID,
+		ProductID:      product.ID,
+		PriceWhenAdded: product.Price,
+	}
+	if err := requestDB(ctx).Create(&item).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	if err := requestDB(ctx).Preload("Items.Product").First(&wishlist, wishlist.ID).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, wishlist)
+}
+
+func RemoveProductFromWishlist(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("product_id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	wishlist, err := findWishlist(ctx, userID, c.Param("wishlist_id"))
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWishlistNotFound)
+	}
+
+	var item models.WishlistItem
+	err = requestDB(ctx).Where("wishlist_id = ? AND product_id = ?", wishlist.ID, productID).First(&item).Error
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotInWishlist)
+	}
+
+	if err := requestDB(ctx).Delete(&item).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	if err := requestDB(ctx).Preload("Items.Product").First(&wishlist, wishlist.ID).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, wishlist)
+}
+
+// MoveWishlistItemToCart adds a wishlisted product to one of the caller's
+// carts and removes it from the wishlist.
+func MoveWishlistItemToCart(c echo.Context) error {
+	ctx := c.Request().Context()
+	productID := c.Param("product_id")
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	wishlist, err := findWishlist(ctx, userID, c.Param("wishlist_id"))
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeWishlistNotFound)
+	}
+
+	var item models.WishlistItem
+	err = requestDB(ctx).Where("wishlist_id = ? AND product_id = ?", wishlist.ID, productID).First(&item).Error
+	if err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeProductNotInWishlist)
+	}
+
+	req := new(moveToCartRequest)
+	if err := c.Bind(req); err != nil {
+		return failure(c, http.StatusBadRequest, i18n.CodeInvalidRequest, err)
+	}
+
+	var cart models.Cart
+	if err := requestDB(ctx).First(&cart, req.CartID).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, i18n.CodeCartNotFound)
+	}
+	if cart.UserID != userID {
+		return errorJSON(c, http.StatusForbidden, i18n.CodeCartOtherUser)
+	}
+
+	updatedCart, err := addProductToCart(ctx,
+		strconv.FormatUint(uint64(cart.ID), 10),
+		strconv.FormatUint(uint64(item.ProductID), 10),
+	)
+	if err != nil {
+		return errorResponse(c, err)
+	}
+
+	if err := requestDB(ctx).Delete(&item).Error; err != nil {
+		return failure(c, http.StatusInternalServerError, i18n.CodeInternal, err)
+	}
+
+	return c.JSON(http.StatusOK, updatedCart)
+}
+
+// notifyPriceDrop runs the registered hooks for every wishlist containing
+// the product when its price went down from oldPrice.
+func notifyPriceDrop(ctx context.Context, product models.Product, oldPrice float64) {
+	if product.Price >= oldPrice || len(priceDropHooks) == 0 {
+		return
+	}
+
+	var drops []PriceDrop
+	err := requestDB(ctx).Model(&models.WishlistItem{}).
+		Select("wishlist_items.wishlist_id, wishlists.user_id, wishlist_items.product_id, wishlist_items.price_when_added").
+		Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id AND wishlists.deleted_at IS NULL").
+		Where("wishlist_items.product_id = ?", product.ID).
+		Scan(&drops).Error
+	if err != nil {
+		logging.FromContext(ctx).Error("loading wishlists for price drop", "product_id", product.ID, "error", err)
+		return
+	}
+
+	for _, drop := range drops {
+		drop.ProductName = product.Name
+		drop.OldPrice = oldPrice
+		drop.NewPrice = product.Price
+		for _, hook := range priceDropHooks {
+			hook(drop)
+		}
+	}
+}
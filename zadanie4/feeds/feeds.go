@@ -0,0 +1,171 @@
+// Package feeds renders the catalogue of a store as product feeds for
+// marketplaces and price-comparison sites: Google Merchant Center RSS,
+// Ceneo XML and plain CSV.
+package feeds
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"shop/models"
+)
+
+// Options describe the store a feed is for.
+type Options struct {
+	Title string
+	// BaseURL is the storefront address product links are built from,
+	// e.g. https://shop.example.com.
+	BaseURL  string
+	Currency string
+}
+
+// Format renders products as one kind of feed.
+type Format struct {
+	ContentType string
+	Render      func(w io.Writer, products []models.Product, opts Options) error
+}
+
+var (
+	GoogleMerchant = Format{ContentType: "application/xml; charset=utf-8", Render: renderGoogleMerchant}
+	Ceneo          = Format{ContentType: "application/xml; charset=utf-8", Render: renderCeneo}
+	CSV            = Format{ContentType: "text/csv; charset=utf-8", Render: renderCSV}
+)
+
+// ProductURL is the storefront page of product.
+func ProductURL(baseURL string, product models.Product) string {
+	return strings.TrimRight(baseURL, "/") + "/products/" + strconv.FormatUint(uint64(product.ID), 10)
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', 2, 64)
+}
+
+type googleFeed struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	NS      string        `xml:"xmlns:g,attr"`
+	Channel googleChannel `xml:"channel"`
+}
+
+type googleChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Items       []googleItem `xml:"item"`
+}
+
+type googleItem struct {
+	ID               string `xml:"g:id"`
+	Title            string `xml:"g:title"`
+	Description      string `xml:"g:description"`
+	Link             string `xml:"g:link"`
+	Price            string `xml:"g:price"`
+	Availability     string `xml:"g:availability"`
+	Condition        string `xml:"g:condition"`
+	ProductType      string `xml:"g:product_type,omitempty"`
+	IdentifierExists string `xml:"g:identifier_exists"`
+}
+
+func renderGoogleMerchant(w io.Writer, products []models.Product, opts Options) error {
+	feed := googleFeed{
+		Version: "2.0",
+		NS:      "http://base.google.com/ns/1.0",
+		Channel: googleChannel{Title: opts.Title, Link: opts.BaseURL, Description: opts.Title},
+	}
+	for _, product := range products {
+		feed.Channel.Items = append(feed.Channel.Items, googleItem{
+			ID:               strconv.FormatUint(uint64(product.ID), 10),
+			Title:            product.Name,
+			Description:      product.Description,
+			Link:             ProductURL(opts.BaseURL, product),
+			Price:            formatPrice(product.Price) + " " + opts.Currency,
+			Availability:     "in_stock",
+			Condition:        "new",
+			ProductType:      product.Category.Name,
+			IdentifierExists: "no",
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type ceneoFeed struct {
+	XMLName xml.Name     `xml:"offers"`
+	NS      string       `xml:"xmlns:xsi,attr"`
+	Version string       `xml:"version,attr"`
+	Offers  []ceneoOffer `xml:"o"`
+}
+
+type ceneoOffer struct {
+	ID       uint   `xml:"id,attr"`
+	URL      string `xml:"url,attr"`
+	Price    string `xml:"price,attr"`
+	Avail    int    `xml:"avail,attr"`
+	Category cdata  `xml:"cat"`
+	Name     cdata  `xml:"name"`
+	Desc     cdata  `xml:"desc"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// Ceneo's avail attribute: 1 means available within 24 hours.
+const ceneoAvailable = 1
+
+func renderCeneo(w io.Writer, products []models.Product, opts Options) error {
+	feed := ceneoFeed{NS: "http://www.w3.org/2001/XMLSchema-instance", Version: "1"}
+	for _, product := range products {
+		feed.Offers = append(feed.Offers, ceneoOffer{
+			ID:       product.ID,
+			URL:      ProductURL(opts.BaseURL, product),
+			Price:    formatPrice(product.Price),
+			Avail:    ceneoAvailable,
+			Category: cdata{product.Category.Name},
+			Name:     cdata{product.Name},
+			Desc:     cdata{product.Description},
+		})
+	}
+	return writeXML(w, feed)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// CSVHeader lists the columns of the CSV feed.
+var CSVHeader = []string{"id", "title", "description", "link", "price", "currency", "category"}
+
+func renderCSV(w io.Writer, products []models.Product, opts Options) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, product := range products {
+		err := cw.Write([]string{
+			strconv.FormatUint(uint64(product.ID), 10),
+			product.Name,
+			product.Description,
+			ProductURL(opts.BaseURL, product),
+			formatPrice(product.Price),
+			opts.Currency,
+			product.Category.Name,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,111 @@
+package i18n
+
+const (
+	// Generic
+	CodeInvalidRequest  Code = "invalid_request"
+	CodeInternal        Code = "internal_error"
+	CodeRouteNotFound   Code = "route_not_found"
+	CodeNotAllowed      Code = "method_not_allowed"
+	CodeTooManyRequests Code = "too_many_requests"
+	CodeNameRequired    Code = "name_required"
+	CodeInvalidPeriod   Code = "invalid_validity_period"
+
+	// Authentication and users
+	CodeUnauthenticated    Code = "unauthenticated"
+	CodeForbidden          Code = "forbidden"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeInvalidEmail       Code = "invalid_email"
+	CodeEmailTaken         Code = "email_taken"
+	CodePasswordTooShort   Code = "password_too_short"
+	CodeInvalidRole        Code = "invalid_role"
+	CodeUserNotFound       Code = "user_not_found"
+	CodeAPIKeyNotFound     Code = "api_key_not_found"
+	CodeAPIKeyRevoked      Code = "api_key_revoked"
+	CodeInvalidScopes      Code = "invalid_scopes"
+	CodeScopeNotHeld       Code = "scope_not_held"
+	CodeInvalidExpiry      Code = "invalid_expiry"
+
+	// Stores
+	CodeStoreNotFound    Code = "store_not_found"
+	CodeInvalidStoreSlug Code = "invalid_store_slug"
+	CodeStoreSlugTaken   Code = "store_slug_taken"
+	CodeStoreIsDefault   Code = "store_is_default"
+	CodeOtherStore       Code = "other_store"
+
+	// Catalogue
+	CodeProductNotFound  Code = "product_not_found"
+	CodeCategoryNotFound Code = "category_not_found"
+
+	// Tax
+	CodeTaxClassNotFound    Code = "tax_class_not_found"
+	CodeTaxClassCodeTaken   Code = "tax_class_code_taken"
+	CodeInvalidTaxClassCode Code = "invalid_tax_class_code"
+	CodeInvalidTaxRate      Code = "invalid_tax_rate"
+	CodeInvalidRegion       Code = "invalid_region"
+
+	// Shipping
+	CodeShippingMethodNotFound  Code = "shipping_method_not_found"
+	CodeShippingMethodCodeTaken Code = "shipping_method_code_taken"
+	CodeInvalidShippingCode     Code = "invalid_shipping_code"
+	CodeInvalidShippingKind     Code = "invalid_shipping_kind"
+	CodeInvalidShippingRule     Code = "invalid_shipping_rule"
+	CodeShippingUnavailable     Code = "shipping_unavailable"
+
+	// Addresses
+	CodeAddressNotFound   Code = "address_not_found"
+	CodeAddressIncomplete Code = "address_incomplete"
+	CodeInvalidCountry    Code = "invalid_country"
+	CodeInvalidPostalCode Code = "invalid_postal_code"
+	CodeInvalidPhone      Code = "invalid_phone"
+
+	// Carts and orders
+	CodeCartNotFound          Code = "cart_not_found"
+	CodeCartEmpty             Code = "cart_empty"
+	CodeCartCheckedOut        Code = "cart_checked_out"
+	CodeCartNotGuest          Code = "cart_not_guest"
+	CodeCartOtherUser         Code = "cart_belongs_to_other_user"
+	CodeInvalidMergeStrategy  Code = "invalid_merge_strategy"
+	CodeOrderNotFound         Code = "order_not_found"
+	CodeGraphQLQueryRequired  Code = "graphql_query_required"
+	CodeProductAlreadyInList  Code = "product_already_in_wishlist"
+	CodeProductNotInWishlist  Code = "product_not_in_wishlist"
+	CodeWishlistNotFound      Code = "wishlist_not_found"
+	CodeWishlistAlreadyExists Code = "wishlist_already_exists"
+
+	// Reviews
+	CodeReviewNotFound      Code = "review_not_found"
+	CodeReviewOtherUser     Code = "review_belongs_to_other_user"
+	CodeReviewOwnVote       Code = "review_own_vote"
+	CodeReviewAlreadyVoted  Code = "review_already_voted"
+	CodeInvalidReviewStatus Code = "invalid_review_status"
+	CodeRatingOutOfRange    Code = "rating_out_of_range"
+
+	// Coupons
+	CodeCouponNotFound       Code = "coupon_not_found"
+	CodeCouponAlreadyExists  Code = "coupon_already_exists"
+	CodeCouponCodeRequired   Code = "coupon_code_required"
+	CodeCouponInvalidType    Code = "coupon_invalid_type"
+	CodeCouponInvalidValue   Code = "coupon_invalid_value"
+	CodeCouponNegativeLimits Code = "coupon_negative_limits"
+	CodeCouponNotActive      Code = "coupon_not_active"
+	CodeCouponMinCartValue   Code = "coupon_min_cart_value"
+	CodeCouponNotApplicable  Code = "coupon_not_applicable"
+	CodeCouponUsageLimit     Code = "coupon_usage_limit"
+	CodeCouponUserLimit      Code = "coupon_user_limit"
+	CodeCouponRequiresUser   Code = "coupon_requires_user"
+
+	// Promotions
+	CodePromotionNotFound        Code = "promotion_not_found"
+	CodePromotionInvalidType     Code = "promotion_invalid_type"
+	CodePromotionInvalidQuantity Code = "promotion_invalid_quantity"
+	CodePromotionInvalidPercent  Code = "promotion_invalid_percentage"
+	CodePromotionNegativeAmount  Code = "promotion_negative_amount"
+	CodePromotionBundleTooSmall  Code = "promotion_bundle_too_small"
+
+	// Webhooks
+	CodeWebhookNotFound       Code = "webhook_not_found"
+	CodeWebhookInvalidURL     Code = "webhook_invalid_url"
+	CodeWebhookInvalidEvents  Code = "webhook_invalid_events"
+	CodeDeliveryNotFound      Code = "webhook_delivery_not_found"
+	CodeInvalidDeliveryStatus Code = "invalid_delivery_status"
+)
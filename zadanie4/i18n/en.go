@@ -0,0 +1,99 @@
+package i18n
+
+var en = map[Code]string{
+	CodeInvalidRequest:  "Invalid request",
+	CodeInternal:        "Internal server error",
+	CodeRouteNotFound:   "Not found",
+	CodeNotAllowed:      "Method not allowed",
+	CodeTooManyRequests: "Too many requests, try again later",
+	CodeNameRequired:    "name is required",
+	CodeInvalidPeriod:   "valid_until must be after valid_from",
+
+	CodeUnauthenticated:    "Authentication required",
+	CodeForbidden:          "You do not have permission to do this",
+	CodeInvalidCredentials: "Invalid email or password",
+	CodeInvalidEmail:       "A valid email is required",
+	CodeEmailTaken:         "An account with this email already exists",
+	CodePasswordTooShort:   "Password must be at least 8 characters long",
+	CodeInvalidRole:        "role must be customer, staff or admin",
+	CodeUserNotFound:       "User not found",
+	CodeAPIKeyNotFound:     "API key not found",
+	CodeAPIKeyRevoked:      "API key has been revoked",
+	CodeInvalidScopes:      "scopes must list at least one known permission",
+	CodeScopeNotHeld:       "A key cannot get a permission you do not have",
+	CodeInvalidExpiry:      "expires_at must be in the future",
+
+	CodeStoreNotFound:    "Store not found",
+	CodeInvalidStoreSlug: "slug must be lower-case letters, digits and hyphens",
+	CodeStoreSlugTaken:   "A store with this slug already exists",
+	CodeStoreIsDefault:   "The default store cannot be deleted or have its slug changed",
+	CodeOtherStore:       "These credentials belong to another store",
+
+	CodeProductNotFound:  "Product not found",
+	CodeCategoryNotFound: "Category not found",
+
+	CodeTaxClassNotFound:    "Tax class not found",
+	CodeTaxClassCodeTaken:   "A tax class with this code already exists",
+	CodeInvalidTaxClassCode: "code must be lower-case letters, digits and underscores",
+	CodeInvalidTaxRate:      "rates must have a two-letter region and a rate from 0 to 100",
+	CodeInvalidRegion:       "region must be a two-letter country code",
+
+	CodeShippingMethodNotFound:  "Shipping method not found",
+	CodeShippingMethodCodeTaken: "A shipping method with this code already exists",
+	CodeInvalidShippingCode:     "code must be lower-case letters, digits and underscores",
+	CodeInvalidShippingKind:     "kind must be courier, parcel_locker or pickup",
+	CodeInvalidShippingRule:     "rules need an empty or two-letter region, non-negative amounts and maximums not below minimums",
+	CodeShippingUnavailable:     "The shipping method is not available for this cart",
+
+	CodeAddressNotFound:   "Address not found",
+	CodeAddressIncomplete: "name, street, city and postal_code are required",
+	CodeInvalidCountry:    "country must be a two-letter country code",
+	CodeInvalidPostalCode: "postal_code does not match the format of the country",
+	CodeInvalidPhone:      "phone must be 6 to 15 digits, optionally starting with +",
+
+	CodeCartNotFound:          "Cart not found",
+	CodeCartEmpty:             "Cart is empty",
+	CodeCartCheckedOut:        "Cart already checked out",
+	CodeCartNotGuest:          "Cart is not a guest cart",
+	CodeCartOtherUser:         "Cart belongs to another user",
+	CodeInvalidMergeStrategy:  "strategy must be sum or newest",
+	CodeOrderNotFound:         "Order not found",
+	CodeGraphQLQueryRequired:  "query is required",
+	CodeProductAlreadyInList:  "Product already in wishlist",
+	CodeProductNotInWishlist:  "Product not in wishlist",
+	CodeWishlistNotFound:      "Wishlist not found",
+	CodeWishlistAlreadyExists: "Wishlist with this name already exists",
+
+	CodeReviewNotFound:      "Review not found",
+	CodeReviewOtherUser:     "Review belongs to another user",
+	CodeReviewOwnVote:       "You cannot vote for your own review",
+	CodeReviewAlreadyVoted:  "You have already marked this review as helpful",
+	CodeInvalidReviewStatus: "Invalid review status",
+	CodeRatingOutOfRange:    "rating must be between 1 and 5",
+
+	CodeCouponNotFound:       "Coupon not found",
+	CodeCouponAlreadyExists:  "Coupon code already exists",
+	CodeCouponCodeRequired:   "code is required",
+	CodeCouponInvalidType:    "type must be percentage or fixed",
+	CodeCouponInvalidValue:   "value must be greater than 0 and at most 100 for percentage coupons",
+	CodeCouponNegativeLimits: "limits must not be negative",
+	CodeCouponNotActive:      "Coupon is not active",
+	CodeCouponMinCartValue:   "Cart value is below the coupon minimum",
+	CodeCouponNotApplicable:  "Coupon does not apply to any product in the cart",
+	CodeCouponUsageLimit:     "Coupon usage limit reached",
+	CodeCouponUserLimit:      "Coupon already used the maximum number of times by this user",
+	CodeCouponRequiresUser:   "Coupon is only available to signed-in users",
+
+	CodePromotionNotFound:        "Promotion not found",
+	CodePromotionInvalidType:     "type must be buy_x_get_y, category_percentage or bundle",
+	CodePromotionInvalidQuantity: "buy_quantity and free_quantity must be at least 1",
+	CodePromotionInvalidPercent:  "percentage must be between 0 and 100",
+	CodePromotionNegativeAmount:  "min_amount and bundle_price must not be negative",
+	CodePromotionBundleTooSmall:  "bundle needs at least two products",
+
+	CodeWebhookNotFound:       "Webhook not found",
+	CodeWebhookInvalidURL:     "url must be an absolute http or https URL",
+	CodeWebhookInvalidEvents:  "events must list at least one known event type or *",
+	CodeDeliveryNotFound:      "Webhook delivery not found",
+	CodeInvalidDeliveryStatus: "status must be pending, succeeded or failed",
+}
@@ -0,0 +1,73 @@
+// Package i18n holds the stable error codes returned by the API and their
+// Polish and English messages.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Code identifies an error independently of the language it is shown in.
+// Codes are part of the API and must not change once released.
+type Code string
+
+const (
+	English = "en"
+	Polish  = "pl"
+)
+
+var catalogues = map[string]map[Code]string{
+	English: en,
+	Polish:  pl,
+}
+
+// Negotiate picks the best supported language from an Accept-Language
+// header such as "pl-PL,pl;q=0.9,en;q=0.8", or fallback when none matches.
+func Negotiate(acceptLanguage, fallback string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogues[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
+
+// Message returns the text of code in lang, falling back to English and
+// then to the code itself.
+func Message(lang string, code Code) string {
+	if message, ok := catalogues[lang][code]; ok {
+		return message
+	}
+	if message, ok := en[code]; ok {
+		return message
+	}
+	return string(code)
+}
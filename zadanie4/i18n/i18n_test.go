@@ -0,0 +1,65 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		lang   string
+	}{
+		{"", English},
+		{"pl", Polish},
+		{"pl-PL,pl;q=0.9,en;q=0.8", Polish},
+		{"en-US,en;q=0.9,pl;q=0.8", English},
+		{"en;q=0.5,pl;q=0.8", Polish},
+		{"en; q=0.5, pl; q=0.8", Polish},
+		{"PL-pl", Polish},
+		{"de-DE,de;q=0.9,pl;q=0.3", Polish},
+		{"de-DE,fr;q=0.9", English},
+		{"pl;q=0,en;q=0.1", English},
+		{"pl;q=0", English},
+		{"pl;q=abc,en;q=0.1", English},
+		{"en;q=0.8,pl;q=0.8", English},
+		{"pl;q=0.8,en;q=0.8", Polish},
+		{"en;q=0.001,pl;q=0.002", Polish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if lang := Negotiate(tt.header, English); lang != tt.lang {
+				t.Errorf("Expected %s for %q, got %s", tt.lang, tt.header, lang)
+			}
+		})
+	}
+}
+
+func TestNegotiateFallback(t *testing.T) {
+	if lang := Negotiate("de", Polish); lang != Polish {
+		t.Errorf("Expected the fallback %s, got %s", Polish, lang)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	if message := Message(Polish, CodeTooManyRequests); message != pl[CodeTooManyRequests] {
+		t.Errorf("Expected the Polish message, got %q", message)
+	}
+	if message := Message("de", CodeTooManyRequests); message != en[CodeTooManyRequests] {
+		t.Errorf("Expected the English message for an unknown language, got %q", message)
+	}
+	if message := Message(Polish, Code("no_such_code")); message != "no_such_code" {
+		t.Errorf("Expected the code itself, got %q", message)
+	}
+}
+
+func TestCataloguesAreComplete(t *testing.T) {
+	for code := range en {
+		if _, ok := pl[code]; !ok {
+			t.Errorf("Expected a Polish message for %s", code)
+		}
+	}
+	for code := range pl {
+		if _, ok := en[code]; !ok {
+			t.Errorf("Expected an English message for %s", code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package i18n
+
+var pl = map[Code]string{
+	CodeInvalidRequest:  "Nieprawidłowe żądanie",
+	CodeInternal:        "Wewnętrzny błąd serwera",
+	CodeRouteNotFound:   "Nie znaleziono",
+	CodeNotAllowed:      "Metoda niedozwolona",
+	CodeTooManyRequests: "Zbyt wiele żądań, spróbuj ponownie później",
+	CodeNameRequired:    "Pole name jest wymagane",
+	CodeInvalidPeriod:   "Data valid_until musi być późniejsza niż valid_from",
+
+	CodeUnauthenticated:    "Wymagane uwierzytelnienie",
+	CodeForbidden:          "Brak uprawnień do wykonania tej operacji",
+	CodeInvalidCredentials: "Nieprawidłowy e-mail lub hasło",
+	CodeInvalidEmail:       "Wymagany jest poprawny adres e-mail",
+	CodeEmailTaken:         "Konto z tym adresem e-mail już istnieje",
+	CodePasswordTooShort:   "Hasło musi mieć co najmniej 8 znaków",
+	CodeInvalidRole:        "Rola musi mieć wartość customer, staff lub admin",
+	CodeUserNotFound:       "Użytkownik nie istnieje",
+	CodeAPIKeyNotFound:     "Klucz API nie istnieje",
+	CodeAPIKeyRevoked:      "Klucz API został unieważniony",
+	CodeInvalidScopes:      "Pole scopes musi zawierać co najmniej jedno znane uprawnienie",
+	CodeScopeNotHeld:       "Klucz nie może otrzymać uprawnienia, którego nie masz",
+	CodeInvalidExpiry:      "Pole expires_at musi wskazywać datę w przyszłości",
+
+	CodeStoreNotFound:    "Sklep nie istnieje",
+	CodeInvalidStoreSlug: "Pole slug może zawierać tylko małe litery, cyfry i myślniki",
+	CodeStoreSlugTaken:   "Sklep o tym identyfikatorze już istnieje",
+	CodeStoreIsDefault:   "Domyślnego sklepu nie można usunąć ani zmienić jego identyfikatora",
+	CodeOtherStore:       "Dane uwierzytelniające należą do innego sklepu",
+
+	CodeProductNotFound:  "Produkt nie istnieje",
+	CodeCategoryNotFound: "Kategoria nie istnieje",
+
+	CodeTaxClassNotFound:    "Klasa podatkowa nie istnieje",
+	CodeTaxClassCodeTaken:   "Klasa podatkowa o tym kodzie już istnieje",
+	CodeInvalidTaxClassCode: "Kod może zawierać tylko małe litery, cyfry i podkreślenia",
+	CodeInvalidTaxRate:      "Stawki muszą mieć dwuliterowy region i wartość od 0 do 100",
+	CodeInvalidRegion:       "Region musi być dwuliterowym kodem kraju",
+
+	CodeShippingMethodNotFound:  "Metoda dostawy nie istnieje",
+	CodeShippingMethodCodeTaken: "Metoda dostawy o tym kodzie już istnieje",
+	CodeInvalidShippingCode:     "Kod może zawierać tylko małe litery, cyfry i podkreślenia",
+	CodeInvalidShippingKind:     "Rodzaj musi mieć wartość courier, parcel_locker lub pickup",
+	CodeInvalidShippingRule:     "Reguły wymagają pustego lub dwuliterowego regionu, nieujemnych kwot i maksimów nie mniejszych od minimów",
+	CodeShippingUnavailable:     "Ta metoda dostawy nie jest dostępna dla tego koszyka",
+
+	CodeAddressNotFound:   "Adres nie istnieje",
+	CodeAddressIncomplete: "Pola name, street, city i postal_code są wymagane",
+	CodeInvalidCountry:    "Kraj musi być dwuliterowym kodem kraju",
+	CodeInvalidPostalCode: "Kod pocztowy nie pasuje do formatu kraju",
+	CodeInvalidPhone:      "Telefon musi mieć od 6 do 15 cyfr, opcjonalnie poprzedzonych znakiem +",
+
+	CodeCartNotFound:          "Koszyk nie istnieje",
+	CodeCartEmpty:             "Koszyk jest pusty",
+	CodeCartCheckedOut:        "Koszyk został już zamówiony",
+	CodeCartNotGuest:          "Koszyk nie jest koszykiem gościa",
+	CodeCartOtherUser:         "Koszyk należy do innego użytkownika",
+	CodeInvalidMergeStrategy:  "Pole strategy musi mieć wartość sum lub newest",
+	CodeOrderNotFound:         "Zamówienie nie istnieje",
+	CodeGraphQLQueryRequired:  "Zapytanie jest wymagane",
+	CodeProductAlreadyInList:  "Produkt jest już na liście życzeń",
+	CodeProductNotInWishlist:  "Produktu nie ma na liście życzeń",
+	CodeWishlistNotFound:      "Lista życzeń nie istnieje",
+	CodeWishlistAlreadyExists: "Lista życzeń o tej nazwie już istnieje",
+
+	CodeReviewNotFound:      "Opinia nie istnieje",
+	CodeReviewOtherUser:     "Opinia należy do innego użytkownika",
+	CodeReviewOwnVote:       "Nie możesz głosować na własną opinię",
+	CodeReviewAlreadyVoted:  "Ta opinia została już przez Ciebie oznaczona jako pomocna",
+	CodeInvalidReviewStatus: "Nieprawidłowy status opinii",
+	CodeRatingOutOfRange:    "Ocena musi być w zakresie od 1 do 5",
+
+	CodeCouponNotFound:       "Kupon nie istnieje",
+	CodeCouponAlreadyExists:  "Kupon o tym kodzie już istnieje",
+	CodeCouponCodeRequired:   "Kod kuponu jest wymagany",
+	CodeCouponInvalidType:    "Typ kuponu musi mieć wartość percentage lub fixed",
+	CodeCouponInvalidValue:   "Wartość kuponu musi być większa od 0, a dla kuponów procentowych nie większa niż 100",
+	CodeCouponNegativeLimits: "Limity nie mogą być ujemne",
+	CodeCouponNotActive:      "Kupon jest nieaktywny",
+	CodeCouponMinCartValue:   "Wartość koszyka jest niższa niż minimum kuponu",
+	CodeCouponNotApplicable:  "Kupon nie dotyczy żadnego produktu w koszyku",
+	CodeCouponUsageLimit:     "Osiągnięto limit użyć kuponu",
+	CodeCouponUserLimit:      "Kupon został już wykorzystany maksymalną liczbę razy przez tego użytkownika",
+	CodeCouponRequiresUser:   "Kupon jest dostępny tylko dla zalogowanych użytkowników",
+
+	CodePromotionNotFound:        "Promocja nie istnieje",
+	CodePromotionInvalidType:     "Typ promocji musi mieć wartość buy_x_get_y, category_percentage lub bundle",
+	CodePromotionInvalidQuantity: "Pola buy_quantity i free_quantity muszą wynosić co najmniej 1",
+	CodePromotionInvalidPercent:  "Procent musi być w zakresie od 0 do 100",
+	CodePromotionNegativeAmount:  "Pola min_amount i bundle_price nie mogą być ujemne",
+	CodePromotionBundleTooSmall:  "Zestaw musi zawierać co najmniej dwa produkty",
+
+	CodeWebhookNotFound:       "Webhook nie istnieje",
+	CodeWebhookInvalidURL:     "Pole url musi być bezwzględnym adresem http lub https",
+	CodeWebhookInvalidEvents:  "Pole events musi zawierać co najmniej jeden znany typ zdarzenia lub *",
+	CodeDeliveryNotFound:      "Dostawa webhooka nie istnieje",
+	CodeInvalidDeliveryStatus: "Status musi mieć wartość pending, succeeded lub failed",
+}
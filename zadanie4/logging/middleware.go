@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"shop/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware assigns every request an ID, taken from X-Request-ID when the
+// client sends a usable one, returns it in the response header and logs one
+// line per request once it has been handled.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			c.SetRequest(req.WithContext(WithRequestID(req.Context(), id)))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("request_id", id),
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.String("route", c.Path()),
+				slog.Int("status", status),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote_ip", c.RealIP()),
+				slog.Int64("bytes_out", c.Response().Size),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			slog.LogAttrs(req.Context(), level, "request", attrs...)
+
+			return err
+		}
+	}
+}
+
+// HTTPErrorHandler renders errors returned by handlers and Echo itself
+// (unknown routes, bind failures) in the API's error format, including the
+// request ID.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Code
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, ErrorBody(c, statusCode(status)))
+	}
+	if err != nil {
+		FromContext(c.Request().Context()).Error("writing error response", "error", err)
+	}
+}
+
+// ErrorBody builds the API's error body: a stable code, its message in the
+// language negotiated from Accept-Language and the request ID.
+func ErrorBody(c echo.Context, code i18n.Code) map[string]string {
+	lang := i18n.Negotiate(c.Request().Header.Get("Accept-Language"), i18n.English)
+	return map[string]string{
+		"code":       string(code),
+		"message":    i18n.Message(lang, code),
+		"request_id": RequestID(c.Request().Context()),
+	}
+}
+
+func statusCode(status int) i18n.Code {
+	switch {
+	case status == http.StatusNotFound:
+		return i18n.CodeRouteNotFound
+	case status == http.StatusMethodNotAllowed:
+		return i18n.CodeNotAllowed
+	case status == http.StatusTooManyRequests:
+		return i18n.CodeTooManyRequests
+	case status >= http.StatusInternalServerError:
+		return i18n.CodeInternal
+	}
+	return i18n.CodeInvalidRequest
+}
@@ -1,40 +1,120 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"shop/auth"
+	"shop/cleanup"
 	"shop/config"
 	"shop/controllers"
+	"shop/logging"
+	"shop/metrics"
+	"shop/migrations"
 	"shop/models"
+	"shop/openapi"
+	"shop/ratelimit"
+	"shop/recommendations"
+	"shop/shoppb"
+	"shop/tax"
+	"shop/tenant"
+	"shop/webhooks"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	config.ConnectDB()
+	logging.Setup(config.StringEnv("LOG_LEVEL", "info"))
 
-	err := config.DB.AutoMigrate(
-		&models.Product{},
-		&models.Category{},
-		&models.Cart{},
-	)
-	if err != nil {
-		log.Fatalf("Błąd migracji: %v", err)
+	config.ConnectDB()
+	if err := metrics.InstrumentDB(config.DB); err != nil {
+		logging.Fatal("Nie można zarejestrować metryk bazy danych", "error", err)
+	}
+	if err := tenant.Register(config.DB); err != nil {
+		logging.Fatal("Nie można zarejestrować izolacji sklepów", "error", err)
 	}
-	categories := []models.Category{
-		{Name: "Electronics"},
-		{Name: "Books"},
-		{Name: "Clothing"},
-		{Name: "Toys"},
-		{Name: "Groceries"},
+	defaultStore := config.StringEnv("DEFAULT_STORE", tenant.DefaultSlug)
+	controllers.ConfigureStores(defaultStore)
+	store := migrate(defaultStore)
+
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1])
+		return
 	}
 
-	for _, category := range categories {
-		if err := config.DB.FirstOrCreate(&category, models.Category{Name: category.Name}).Error; err != nil {
-			log.Printf("Error seeding category %v: %v", category.Name, err)
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		db := config.DB.WithContext(tenant.WithStore(context.Background(), store))
+		created, err := auth.EnsureAdmin(db, email, password)
+		if err != nil {
+			logging.Fatal("Nie można utworzyć administratora", "error", err)
+		}
+		if created {
+			slog.Info("created admin user", "email", email)
 		}
 	}
+
+	controllers.RegisterPriceDropHook(func(drop controllers.PriceDrop) {
+		slog.Info("price drop",
+			"user_id", drop.UserID,
+			"wishlist_id", drop.WishlistID,
+			"product_id", drop.ProductID,
+			"old_price", drop.OldPrice,
+			"new_price", drop.NewPrice,
+		)
+	})
+
+	go cleanup.Start(
+		context.Background(),
+		config.DB.WithContext(tenant.AllStores(context.Background())),
+		config.DurationEnv("CART_CLEANUP_INTERVAL", time.Hour),
+		config.DurationEnv("CART_INACTIVITY_TTL", 72*time.Hour),
+	)
+
+	go recommendations.Start(
+		context.Background(),
+		config.DB.WithContext(tenant.AllStores(context.Background())),
+		config.DurationEnv("RECOMMENDATIONS_INTERVAL", time.Hour),
+		config.IntEnv("RECOMMENDATIONS_MIN_SUPPORT", 2),
+		config.IntEnv("RECOMMENDATIONS_PER_PRODUCT", 20),
+	)
+
+	controllers.ConfigureCatalogCache(
+		config.IntEnv("CATALOG_CACHE_SIZE", 1000),
+		config.DurationEnv("CATALOG_CACHE_TTL", 5*time.Minute),
+	)
+
+	controllers.ConfigureTax(taxConfig())
+
+	controllers.ConfigureFeeds(config.StringEnv("FEED_BASE_URL", ""), config.StringEnv("FEED_CURRENCY", "PLN"))
+
+	dispatcher := &webhooks.Dispatcher{
+		DB:          config.DB,
+		Client:      &http.Client{Timeout: config.DurationEnv("WEBHOOK_TIMEOUT", 10*time.Second)},
+		MaxAttempts: config.IntEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		BaseBackoff: config.DurationEnv("WEBHOOK_BACKOFF", 30*time.Second),
+		MaxBackoff:  config.DurationEnv("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+	}
+	go dispatcher.Start(tenant.AllStores(context.Background()), config.DurationEnv("WEBHOOK_INTERVAL", 5*time.Second))
+
+	go startGRPC(config.StringEnv("GRPC_ADDR", ":9090"), defaultStore)
+
 	e := echo.New()
+	e.HideBanner = true
+	e.HTTPErrorHandler = logging.HTTPErrorHandler
+	e.IPExtractor = ipExtractor()
+	e.Use(logging.Middleware())
+	e.Use(metrics.Middleware())
+	// The limiter goes first so that failed logins and made-up keys are
+	// throttled too.
+	e.Use(ratelimit.Middleware(rateLimitConfig()))
+	e.Use(tenant.Middleware(config.DB, defaultStore))
+	e.Use(auth.Middleware(config.DB))
 
 	e.GET("/", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Witaj w Go Echo Shop!")
@@ -42,22 +122,243 @@ func main() {
 
 	initRoutes(e)
 
-	e.Logger.Fatal(e.Start(":8080"))
+	if err := e.Start(":8080"); err != nil {
+		logging.Fatal("Błąd serwera HTTP", "error", err)
+	}
+}
+
+// startGRPC serves the catalogue and cart services next to the REST API.
+// Server reflection lets tools like grpcurl discover them without the proto.
+func startGRPC(addr, defaultStore string) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		logging.Fatal("Nie można uruchomić serwera gRPC", "error", err)
+	}
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logging.UnaryServerInterceptor(),
+		tenant.UnaryServerInterceptor(config.DB, defaultStore),
+		auth.UnaryServerInterceptor(config.DB, map[string]auth.Permission{
+			shoppb.CatalogService_CreateProduct_FullMethodName: auth.PermCatalogWrite,
+			shoppb.CatalogService_UpdateProduct_FullMethodName: auth.PermCatalogWrite,
+			shoppb.CatalogService_DeleteProduct_FullMethodName: auth.PermCatalogWrite,
+		}),
+	))
+	controllers.RegisterGRPCServices(s)
+	reflection.Register(s)
+
+	slog.Info("gRPC server listening", "addr", addr)
+	if err := s.Serve(lis); err != nil {
+		logging.Fatal("Błąd serwera gRPC", "error", err)
+	}
+}
+
+// rateLimitConfig reads the token bucket limits, e.g. RATE_LIMIT_IP=120/1m
+// and RATE_LIMIT_ROUTES="POST /products=10/1m,POST /carts/:id/checkout=5/1m".
+func rateLimitConfig() ratelimit.Config {
+	cfg := ratelimit.Config{Store: ratelimit.NewMemoryStore(), ValidateAPIKey: auth.ValidateAPIKey(config.DB)}
+
+	var err error
+	if cfg.IP, err = ratelimit.ParseLimit(config.StringEnv("RATE_LIMIT_IP", "120/1m")); err != nil {
+		logging.Fatal("Błędny RATE_LIMIT_IP", "error", err)
+	}
+	if cfg.APIKey, err = ratelimit.ParseLimit(config.StringEnv("RATE_LIMIT_API_KEY", "600/1m")); err != nil {
+		logging.Fatal("Błędny RATE_LIMIT_API_KEY", "error", err)
+	}
+	if cfg.Routes, err = ratelimit.ParseRoutes(config.StringEnv("RATE_LIMIT_ROUTES", "POST /products=10/1m,POST /carts/:id/checkout=5/1m")); err != nil {
+		logging.Fatal("Błędny RATE_LIMIT_ROUTES", "error", err)
+	}
+
+	return cfg
+}
+
+// ipExtractor reads the client IP from X-Forwarded-For only when the
+// connection comes from a proxy listed in TRUSTED_PROXIES, e.g.
+// "10.0.0.0/8,172.16.0.0/12"; without it the peer address is used, so
+// clients cannot pick their rate limit bucket.
+func ipExtractor() echo.IPExtractor {
+	proxies := config.StringEnv("TRUSTED_PROXIES", "")
+	if strings.TrimSpace(proxies) == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range strings.Split(proxies, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			logging.Fatal("Błędny TRUSTED_PROXIES", "error", err)
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// migrate upgrades the schema and seeds the default store, which it returns.
+func migrate(defaultStore string) models.Store {
+	store, err := migrations.Run(config.DB, defaultStore)
+	if err != nil {
+		logging.Fatal("Błąd migracji", "error", err)
+	}
+
+	db := config.DB.WithContext(tenant.WithStore(context.Background(), store))
+	if err := migrations.SeedCategories(db); err != nil {
+		slog.Error("seeding categories", "error", err)
+	}
+	if err := migrations.SeedShippingMethods(db); err != nil {
+		slog.Error("seeding shipping methods", "error", err)
+	}
+	if err := migrations.SeedTaxClasses(db, taxConfig().Region); err != nil {
+		slog.Error("seeding tax classes", "error", err)
+	}
+	return store
+}
+
+// taxConfig reads the default tax region and how prices are taxed and shown.
+func taxConfig() tax.Config {
+	return tax.Config{
+		Region:           strings.ToUpper(config.StringEnv("TAX_REGION", "PL")),
+		PricesIncludeTax: config.StringEnv("PRICES_INCLUDE_TAX", "true") != "false",
+		DisplayMode:      config.StringEnv("PRICE_DISPLAY", tax.ModeGross),
+	}
+}
+
+// runCommand handles one-off maintenance commands, e.g. `main expire-carts`.
+func runCommand(name string) {
+	switch name {
+	case "expire-carts":
+		count, err := cleanup.ExpireCarts(config.DB.WithContext(tenant.AllStores(context.Background())), config.DurationEnv("CART_INACTIVITY_TTL", 72*time.Hour))
+		if err != nil {
+			logging.Fatal("expiring carts", "error", err)
+		}
+		slog.Info("expired abandoned carts", "count", count)
+	default:
+		logging.Fatal("unknown command", "command", name)
+	}
 }
 
 func initRoutes(e *echo.Echo) {
 	p := e.Group("/products")
-	p.POST("", controllers.CreateProduct)
+	p.POST("", controllers.CreateProduct, auth.Require(auth.PermCatalogWrite))
 	p.GET("", controllers.GetProducts)
 	p.GET("/:id", controllers.GetProductByID)
-	p.PUT("/:id", controllers.UpdateProduct)
-	p.DELETE("/:id", controllers.DeleteProduct)
+	p.PUT("/:id", controllers.UpdateProduct, auth.Require(auth.PermCatalogWrite))
+	p.DELETE("/:id", controllers.DeleteProduct, auth.Require(auth.PermCatalogWrite))
 
 	p.GET("/scopes", controllers.GetProductsWithScopes)
+	p.GET("/:id/recommendations", controllers.GetProductRecommendations)
+
+	p.POST("/:id/reviews", controllers.CreateReview)
+	p.GET("/:id/reviews", controllers.GetProductReviews)
+
+	r := e.Group("/reviews")
+	r.GET("", controllers.GetReviews, auth.Require(auth.PermReviewsModerate))
+	r.PUT("/:id", controllers.UpdateReview)
+	r.DELETE("/:id", controllers.DeleteReview)
+	r.POST("/:id/helpful", controllers.MarkReviewHelpful)
+	r.PUT("/:id/moderation", controllers.ModerateReview, auth.Require(auth.PermReviewsModerate))
 
 	cart := e.Group("/carts")
 	cart.POST("", controllers.CreateCart)
 	cart.GET("/:id", controllers.GetCartByID)
 	cart.POST("/:cart_id/add-product/:product_id", controllers.AddProductToCart)
 	cart.DELETE("/:cart_id/remove-product/:product_id", controllers.RemoveProductFromCart)
+	cart.POST("/:id/coupon", controllers.ApplyCouponToCart)
+	cart.DELETE("/:id/coupon", controllers.RemoveCouponFromCart)
+	cart.POST("/:id/checkout", controllers.CheckoutCart)
+	cart.POST("/:id/merge", controllers.MergeGuestCart)
+	cart.GET("/:id/shipping-quotes", controllers.GetCartShippingQuotes)
+	cart.PUT("/:id/shipping", controllers.SetCartShippingMethod)
+
+	manageShipping := auth.Require(auth.PermShippingManage)
+	shippingMethods := e.Group("/shipping-methods")
+	shippingMethods.GET("", controllers.GetShippingMethods)
+	shippingMethods.POST("", controllers.CreateShippingMethod, manageShipping)
+	shippingMethods.PUT("/:id", controllers.UpdateShippingMethod, manageShipping)
+	shippingMethods.DELETE("/:id", controllers.DeleteShippingMethod, manageShipping)
+
+	coupons := e.Group("/coupons")
+	coupons.POST("", controllers.CreateCoupon, auth.Require(auth.PermPromotionsManage))
+	coupons.GET("", controllers.GetCoupons, auth.Require(auth.PermPromotionsManage))
+	coupons.DELETE("/:id", controllers.DeleteCoupon, auth.Require(auth.PermPromotionsManage))
+
+	promotions := e.Group("/promotions")
+	promotions.POST("", controllers.CreatePromotion, auth.Require(auth.PermPromotionsManage))
+	promotions.GET("", controllers.GetPromotions)
+	promotions.PUT("/:id", controllers.UpdatePromotion, auth.Require(auth.PermPromotionsManage))
+	promotions.DELETE("/:id", controllers.DeletePromotion, auth.Require(auth.PermPromotionsManage))
+
+	e.GET("/orders/:id", controllers.GetOrderByID)
+
+	taxClasses := e.Group("/tax-classes")
+	taxClasses.GET("", controllers.GetTaxClasses)
+	taxClasses.POST("", controllers.CreateTaxClass, auth.Require(auth.PermTaxManage))
+	taxClasses.PUT("/:id", controllers.UpdateTaxClass, auth.Require(auth.PermTaxManage))
+	e.PUT("/categories/:id/tax-class", controllers.UpdateCategoryTaxClass, auth.Require(auth.PermCatalogWrite))
+
+	w := e.Group("/wishlists")
+	signedIn := auth.RequireUser()
+	w.POST("", controllers.CreateWishlist, signedIn)
+	w.GET("", controllers.GetWishlists, signedIn)
+	w.GET("/:id", controllers.GetWishlistByID, signedIn)
+	w.DELETE("/:id", controllers.DeleteWishlist, signedIn)
+	w.POST("/:wishlist_id/add-product/:product_id", controllers.AddProductToWishlist, signedIn)
+	w.DELETE("/:wishlist_id/remove-product/:product_id", controllers.RemoveProductFromWishlist, signedIn)
+	w.POST("/:wishlist_id/move-to-cart/:product_id", controllers.MoveWishlistItemToCart, signedIn)
+
+	f := e.Group("/feeds")
+	f.GET("/google-merchant.xml", controllers.GetGoogleMerchantFeed)
+	f.GET("/ceneo.xml", controllers.GetCeneoFeed)
+	f.GET("/products.csv", controllers.GetCSVFeed)
+
+	e.GET("/graphql", controllers.GraphQL)
+	e.POST("/graphql", controllers.GraphQL)
+
+	spec := openapi.Build("Go Echo Shop API", "1.0.0", controllers.Operations)
+	e.GET("/openapi.json", openapi.SpecHandler(spec))
+	e.GET("/docs", openapi.DocsHandler("Go Echo Shop API", "/openapi.json"))
+
+	e.GET("/metrics", metrics.Handler())
+
+	manageHooks := auth.Require(auth.PermWebhooksManage)
+	hooks := e.Group("/webhooks")
+	hooks.POST("", controllers.CreateWebhook, manageHooks)
+	hooks.GET("", controllers.GetWebhooks, manageHooks)
+	hooks.GET("/events", controllers.GetWebhookEventTypes, manageHooks)
+	hooks.GET("/:id", controllers.GetWebhookByID, manageHooks)
+	hooks.PUT("/:id", controllers.UpdateWebhook, manageHooks)
+	hooks.DELETE("/:id", controllers.DeleteWebhook, manageHooks)
+	hooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries, manageHooks)
+	hooks.POST("/:id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook, manageHooks)
+
+	a := e.Group("/auth")
+	a.POST("/register", controllers.Register)
+	a.POST("/login", controllers.Login)
+	a.POST("/logout", controllers.Logout)
+	a.GET("/me", controllers.Me)
+
+	addresses := e.Group("/addresses")
+	addresses.GET("", controllers.GetAddresses)
+	addresses.POST("", controllers.CreateAddress)
+	addresses.GET("/:id", controllers.GetAddressByID)
+	addresses.PUT("/:id", controllers.UpdateAddress)
+	addresses.DELETE("/:id", controllers.DeleteAddress)
+
+	admin := e.Group("/admin")
+	admin.DELETE("/cache", controllers.FlushCache, auth.Require(auth.PermCacheFlush))
+	admin.GET("/users", controllers.GetUsers, auth.Require(auth.PermUsersManage))
+	admin.PUT("/users/:id/role", controllers.UpdateUserRole, auth.Require(auth.PermUsersManage))
+
+	manageKeys := auth.Require(auth.PermAPIKeysManage)
+	admin.POST("/api-keys", controllers.CreateAPIKey, manageKeys)
+	admin.GET("/api-keys", controllers.GetAPIKeys, manageKeys)
+	admin.GET("/api-keys/scopes", controllers.GetAPIKeyScopes, manageKeys)
+	admin.PUT("/api-keys/:id", controllers.UpdateAPIKey, manageKeys)
+	admin.DELETE("/api-keys/:id", controllers.RevokeAPIKey, manageKeys)
+	admin.POST("/api-keys/:id/rotate", controllers.RotateAPIKey, manageKeys)
+
+	manageStores := auth.Require(auth.PermStoresManage)
+	admin.POST("/stores", controllers.CreateStore, manageStores, controllers.DefaultStoreOnly)
+	admin.GET("/stores", controllers.GetStores, manageStores, controllers.DefaultStoreOnly)
+	admin.PUT("/stores/:id", controllers.UpdateStore, manageStores, controllers.DefaultStoreOnly)
+	admin.DELETE("/stores/:id", controllers.DeleteStore, manageStores, controllers.DefaultStoreOnly)
 }
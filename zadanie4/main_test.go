@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"shop/controllers"
+	"shop/openapi"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	e := echo.New()
+	initRoutes(e)
+
+	routes := map[string]bool{}
+	for _, route := range e.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	documented := map[string]bool{}
+	for _, operation := range controllers.Operations {
+		documented[operation.Route()] = true
+		if !routes[operation.Route()] {
+			t.Errorf("%s is documented but not registered", operation.Route())
+		}
+	}
+
+	for route := range routes {
+		if !documented[route] {
+			t.Errorf("%s is registered but missing from controllers.Operations", route)
+		}
+	}
+}
+
+func TestOpenAPISpecIsValidJSON(t *testing.T) {
+	spec := openapi.Build("Go Echo Shop API", "1.0.0", controllers.Operations)
+
+	body, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("Failed to marshal spec: %s", err)
+	}
+
+	var decoded struct {
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Failed to decode spec: %s", err)
+	}
+
+	if _, ok := decoded.Paths["/products/{id}"]["get"]; !ok {
+		t.Errorf("Expected GET /products/{id} in spec")
+	}
+	for _, name := range []string{"Product", "Category", "Cart", "Review", "Error"} {
+		if _, ok := decoded.Components.Schemas[name]; !ok {
+			t.Errorf("Expected schema %s", name)
+		}
+	}
+}
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		remote  string
+		xff     string
+		ip      string
+	}{
+		{name: "no trusted proxies uses the peer", remote: "10.1.2.3:4000", xff: "198.51.100.7", ip: "10.1.2.3"},
+		{name: "trusted proxy forwards the client", proxies: "10.0.0.0/8", remote: "10.1.2.3:4000", xff: "198.51.100.7", ip: "198.51.100.7"},
+		{name: "untrusted peer cannot forward", proxies: "10.0.0.0/8", remote: "203.0.113.5:4000", xff: "198.51.100.7", ip: "203.0.113.5"},
+		{name: "private peers outside the list are not trusted", proxies: "10.0.0.0/8", remote: "192.168.1.1:4000", xff: "198.51.100.7", ip: "192.168.1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.proxies)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set(echo.HeaderXForwardedFor, tt.xff)
+			if ip := ipExtractor()(req); ip != tt.ip {
+				t.Errorf("Expected IP %s, got %s", tt.ip, ip)
+			}
+		})
+	}
+}
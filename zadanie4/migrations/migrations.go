@@ -0,0 +1,217 @@
+// Package migrations creates and upgrades the database schema and seeds
+// reference data. It is shared by the server and shopctl.
+package migrations
+
+import (
+	"errors"
+
+	"shop/models"
+	"shop/tax"
+	"shop/tenant"
+
+	"gorm.io/gorm"
+)
+
+// Models lists every table managed by AutoMigrate.
+var Models = []interface{}{
+	&models.Store{},
+	&models.Product{},
+	&models.Category{},
+	&models.Cart{},
+	&models.CartItem{},
+	&models.Review{},
+	&models.ReviewVote{},
+	&models.Wishlist{},
+	&models.WishlistItem{},
+	&models.Coupon{},
+	&models.CouponRedemption{},
+	&models.TaxClass{},
+	&models.TaxRate{},
+	&models.ShippingMethod{},
+	&models.ShippingRule{},
+	&models.Order{},
+	&models.OrderItem{},
+	&models.OrderTaxLine{},
+	&models.Promotion{},
+	&models.CartAbandonment{},
+	&models.ProductAssociation{},
+	&models.WebhookSubscription{},
+	&models.WebhookEvent{},
+	&models.WebhookDelivery{},
+	&models.WebhookDeliveryAttempt{},
+	&models.User{},
+	&models.Session{},
+	&models.Address{},
+	&models.APIKey{},
+}
+
+// tenanted lists the store-scoped models, whose rows from before
+// multi-tenancy belong to the default store.
+var tenanted = []interface{}{
+	&models.Product{},
+	&models.Category{},
+	&models.Cart{},
+	&models.Order{},
+	&models.Review{},
+	&models.Wishlist{},
+	&models.Coupon{},
+	&models.CouponRedemption{},
+	&models.TaxClass{},
+	&models.Promotion{},
+	&models.WebhookSubscription{},
+	&models.WebhookEvent{},
+	&models.User{},
+	&models.Address{},
+	&models.APIKey{},
+}
+
+// globalIndexes are unique indexes replaced by ones per store.
+var globalIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{&models.Coupon{}, "idx_coupons_code"},
+	{&models.TaxClass{}, "idx_tax_classes_code"},
+	{&models.User{}, "idx_users_email"},
+}
+
+// DefaultCategories are created in the default store on every start.
+var DefaultCategories = []string{"Electronics", "Books", "Clothing", "Toys", "Groceries"}
+
+// sampleProducts are added by Seed with products, keyed by category.
+var sampleProducts = map[string][]models.Product{
+	"Electronics": {
+		{Name: "Laptop", Description: "Wydajny laptop dla programistów", Price: 3999.99, Weight: 2.2, Length: 45, Width: 32, Height: 8},
+		{Name: "Smartphone", Description: "Smartfon z dużym ekranem", Price: 1999.99, Weight: 0.4, Length: 20, Width: 10, Height: 6},
+	},
+	"Books":     {{Name: "The Go Programming Language", Description: "Donovan & Kernighan", Price: 159.00, Weight: 0.8, Length: 24, Width: 19, Height: 3}},
+	"Clothing":  {{Name: "T-shirt", Description: "Bawełniana koszulka", Price: 49.99, Weight: 0.2, Length: 30, Width: 25, Height: 2}},
+	"Toys":      {{Name: "Puzzle 1000", Description: "Puzzle z 1000 elementów", Price: 69.90, Weight: 0.9, Length: 38, Width: 27, Height: 6}},
+	"Groceries": {{Name: "Kawa ziarnista", Description: "1 kg, średnio palona", Price: 89.00, Weight: 1.05, Length: 25, Width: 12, Height: 8}},
+}
+
+// DefaultShippingMethods are created in the default store on every start.
+// The parcel locker takes the largest locker size.
+var DefaultShippingMethods = []models.ShippingMethod{
+	{
+		Code: "courier", Name: "Kurier", Kind: models.ShippingCourier, Active: true,
+		Rules: []models.ShippingRule{
+			{Region: "PL", MaxWeight: 10, Price: 16.99, FreeAbove: 300},
+			{Region: "PL", MinWeight: 10, MaxWeight: 31.5, Price: 24.99, FreeAbove: 300},
+			{MaxWeight: 31.5, Price: 69.99},
+		},
+	},
+	{
+		Code: "parcel_locker", Name: "Paczkomat", Kind: models.ShippingParcelLocker, Active: true,
+		MaxLength: 64, MaxWidth: 38, MaxHeight: 41,
+		Rules: []models.ShippingRule{{Region: "PL", MaxWeight: 25, Price: 12.99, FreeAbove: 200}},
+	},
+	{
+		Code: "pickup", Name: "Odbiór osobisty", Kind: models.ShippingPickup, Active: true,
+		Rules: []models.ShippingRule{{Region: "PL"}},
+	},
+}
+
+// Run migrates the schema and makes sure the default store exists, moving
+// rows created before multi-tenancy into it.
+func Run(db *gorm.DB, defaultStore string) (models.Store, error) {
+	if err := db.AutoMigrate(Models...); err != nil {
+		return models.Store{}, err
+	}
+	for _, index := range globalIndexes {
+		if db.Migrator().HasIndex(index.model, index.name) {
+			if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+				return models.Store{}, err
+			}
+		}
+	}
+	return tenant.EnsureDefault(db, defaultStore, tenanted...)
+}
+
+// SeedCategories creates DefaultCategories in the store of db's context
+// unless they exist.
+func SeedCategories(db *gorm.DB) error {
+	var errs []error
+	for _, name := range DefaultCategories {
+		category := models.Category{Name: name}
+		if err := db.FirstOrCreate(&category, models.Category{Name: name}).Error; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// taxClassNames are the names of the seeded tax classes.
+var taxClassNames = map[string]string{
+	models.TaxClassStandard:     "Stawka podstawowa",
+	models.TaxClassReduced:      "Stawka obniżona",
+	models.TaxClassSuperReduced: "Stawka super obniżona",
+	models.TaxClassZero:         "Stawka zerowa",
+}
+
+// SeedTaxClasses creates the Polish VAT classes with their rates in region
+// in the store of db's context unless they exist. Existing rates are left alone, as they may have been
+// changed since.
+func SeedTaxClasses(db *gorm.DB, region string) error {
+	for code, rate := range tax.DefaultRates {
+		class := models.TaxClass{Code: code, Name: taxClassNames[code]}
+		if err := db.Where(models.TaxClass{Code: code}).FirstOrCreate(&class).Error; err != nil {
+			return err
+		}
+		taxRate := models.TaxRate{TaxClassID: class.ID, Region: region, Rate: rate}
+		if err := db.Where(models.TaxRate{TaxClassID: class.ID, Region: region}).FirstOrCreate(&taxRate).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedShippingMethods creates DefaultShippingMethods in the store of db's
+// context unless a method with the same code exists.
+func SeedShippingMethods(db *gorm.DB) error {
+	var errs []error
+	for _, sample := range DefaultShippingMethods {
+		var count int64
+		if err := db.Model(&models.ShippingMethod{}).Where("code = ?", sample.Code).Count(&count).Error; err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		method := sample
+		method.Rules = append([]models.ShippingRule(nil), sample.Rules...)
+		if err := db.Create(&method).Error; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SeedProducts adds a few sample products to the default categories of the
+// store of db's context, skipping products that exist. It returns how many
+// were created.
+func SeedProducts(db *gorm.DB) (int, error) {
+	if err := SeedCategories(db); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, categoryName := range DefaultCategories {
+		var category models.Category
+		if err := db.Where("name = ?", categoryName).First(&category).Error; err != nil {
+			return created, err
+		}
+
+		for _, sample := range sampleProducts[categoryName] {
+			product := sample
+			product.CategoryID = category.ID
+			result := db.Where(models.Product{Name: product.Name}).Attrs(product).FirstOrCreate(&product)
+			if result.Error != nil {
+				return created, result.Error
+			}
+			created += int(result.RowsAffected)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import "gorm.io/gorm"
+
+// PostalAddress is where an order is shipped or billed to. Country is an
+// ISO 3166 code such as PL.
+type PostalAddress struct {
+	Name       string `json:"name"`
+	Company    string `json:"company"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+// Address is an entry of a user's address book. A user with addresses has
+// exactly one default for shipping and one for billing; checkout uses them
+// unless the order names others.
+type Address struct {
+	gorm.Model
+	StoreID         uint   `gorm:"index" json:"store_id"`
+	UserID          uint   `gorm:"index" json:"user_id"`
+	Label           string `json:"label"`
+	PostalAddress   `gorm:"embedded"`
+	DefaultShipping bool `json:"default_shipping"`
+	DefaultBilling  bool `json:"default_billing"`
+}
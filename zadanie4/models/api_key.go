@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates an integration rather than a person. Instead of a
+// role it carries the permissions it was scoped to, in the store it was
+// created in. Only the SHA-256 of the key is stored; Key is set only in the
+// response that creates or rotates it.
+type APIKey struct {
+	gorm.Model
+	StoreID     uint       `gorm:"index" json:"store_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	KeyHash     string     `gorm:"uniqueIndex" json:"-"`
+	Scopes      []string   `gorm:"serializer:json" json:"scopes"`
+	CreatedByID uint       `json:"created_by_id"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `gorm:"index" json:"revoked_at"`
+	Key         string     `gorm:"-" json:"key,omitempty"`
+}
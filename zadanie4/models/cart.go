@@ -6,6 +6,60 @@ import (
 
 type Cart struct {
 	gorm.Model
-	UserID   uint      `json:"user_id"`
-	Products []Product `gorm:"many2many:cart_products;" json:"products"`
+	StoreID          uint            `gorm:"index" json:"store_id"`
+	UserID           uint            `json:"user_id"`
+	Region           string          `json:"region"`
+	Products         []Product       `gorm:"many2many:cart_products;" json:"products"`
+	Items            []CartItem      `gorm:"foreignKey:CartID" json:"items"`
+	CouponID         *uint           `json:"coupon_id"`
+	ShippingMethodID *uint           `json:"shipping_method_id"`
+	ShippingMethod   *ShippingMethod `json:"shipping_method,omitempty"`
+	Coupon           *Coupon         `json:"coupon,omitempty"`
+	Subtotal         float64         `gorm:"-" json:"subtotal"`
+	Discount         float64         `gorm:"-" json:"discount"`
+	Total            float64         `gorm:"-" json:"total"`
+
+	PromotionDiscount float64            `gorm:"-" json:"promotion_discount"`
+	Promotions        []AppliedPromotion `gorm:"-" json:"promotions"`
+
+	Weight   float64   `gorm:"-" json:"weight"`
+	Shipping float64   `gorm:"-" json:"shipping"`
+	Net      float64   `gorm:"-" json:"net"`
+	Tax      float64   `gorm:"-" json:"tax"`
+	TaxLines []TaxLine `gorm:"-" json:"tax_lines"`
+}
+
+// CartItem is a row of cart_products, the join table behind Cart.Products,
+// with how many units of the product the cart holds.
+type CartItem struct {
+	CartID    uint `gorm:"primaryKey;autoIncrement:false" json:"cart_id"`
+	ProductID uint `gorm:"primaryKey;autoIncrement:false" json:"product_id"`
+	Quantity  int  `gorm:"not null;default:1" json:"quantity"`
+}
+
+func (CartItem) TableName() string {
+	return "cart_products"
+}
+
+// Quantity is how many units of the product the cart holds. It needs Items
+// loaded; a product without an item counts once.
+func (c Cart) Quantity(productID uint) int {
+	for _, item := range c.Items {
+		if item.ProductID == productID && item.Quantity > 0 {
+			return item.Quantity
+		}
+	}
+	return 1
+}
+
+// Units lists the cart's products once per unit, which is what totals,
+// discounts, tax and shipping are computed from.
+func (c Cart) Units() []Product {
+	units := make([]Product, 0, len(c.Products))
+	for _, product := range c.Products {
+		for i := c.Quantity(product.ID); i > 0; i-- {
+			units = append(units, product)
+		}
+	}
+	return units
 }
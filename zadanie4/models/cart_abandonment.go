@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CartAbandonment is recorded when an inactive cart is expired, so
+// abandonment can be reported on after the cart itself is gone.
+type CartAbandonment struct {
+	gorm.Model
+	CartID         uint      `gorm:"index" json:"cart_id"`
+	UserID         uint      `gorm:"index" json:"user_id"`
+	ProductCount   int       `json:"product_count"`
+	Subtotal       float64   `json:"subtotal"`
+	CouponID       *uint     `json:"coupon_id"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
@@ -4,6 +4,8 @@ import "gorm.io/gorm"
 
 type Category struct {
 	gorm.Model
-	Name     string `json:"name"`
-	Products []Product
+	StoreID    uint   `gorm:"index" json:"store_id"`
+	Name       string `json:"name"`
+	TaxClassID *uint  `json:"tax_class_id"`
+	Products   []Product
 }
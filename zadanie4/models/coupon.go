@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+var (
+	ErrCouponNotActive     = errors.New("coupon is not active")
+	ErrCouponMinCartValue  = errors.New("cart value is below the coupon minimum")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any product in the cart")
+	ErrCouponUsageLimit    = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit     = errors.New("coupon already used the maximum number of times by this user")
+	ErrCouponRequiresUser  = errors.New("coupon is only available to signed-in users")
+)
+
+type Coupon struct {
+	gorm.Model
+	StoreID           uint       `gorm:"uniqueIndex:idx_coupons_store_code" json:"store_id"`
+	Code              string     `gorm:"uniqueIndex:idx_coupons_store_code" json:"code"`
+	Type              string     `json:"type"`
+	Value             float64    `json:"value"`
+	MinCartValue      float64    `json:"min_cart_value"`
+	ValidFrom         *time.Time `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until"`
+	UsageLimit        int        `json:"usage_limit"`
+	UsageLimitPerUser int        `json:"usage_limit_per_user"`
+	Categories        []Category `gorm:"many2many:coupon_categories;" json:"categories"`
+}
+
+type CouponRedemption struct {
+	gorm.Model
+	StoreID  uint `gorm:"index" json:"store_id"`
+	CouponID uint `gorm:"index" json:"coupon_id"`
+	UserID   uint `gorm:"index" json:"user_id"`
+	OrderID  uint `json:"order_id"`
+}
+
+func (c *Coupon) IsActive(now time.Time) bool {
+	if c.ValidFrom != nil && now.Before(*c.ValidFrom) {
+		return false
+	}
+	if c.ValidUntil != nil && now.After(*c.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo reports whether the coupon covers the product. Coupons without
+// categories cover the whole catalogue.
+func (c *Coupon) AppliesTo(product Product) bool {
+	if len(c.Categories) == 0 {
+		return true
+	}
+	for _, category := range c.Categories {
+		if category.ID == product.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckUsage returns an error when the coupon may not be redeemed again,
+// given how many times it was redeemed in total and by the user, whose ID
+// is 0 for guests. Coupons with a per-user limit need a user.
+func (c *Coupon) CheckUsage(used, usedByUser int64, userID uint) error {
+	if c.UsageLimit > 0 && used >= int64(c.UsageLimit) {
+		return ErrCouponUsageLimit
+	}
+	if c.UsageLimitPerUser > 0 {
+		if userID == 0 {
+			return ErrCouponRequiresUser
+		}
+		if usedByUser >= int64(c.UsageLimitPerUser) {
+			return ErrCouponUserLimit
+		}
+	}
+	return nil
+}
+
+// Discount returns the amount taken off the given products, or an error
+// explaining why the coupon cannot be used. Usage limits are checked
+// separately since they need the database.
+func (c *Coupon) Discount(products []Product, now time.Time) (float64, error) {
+	if !c.IsActive(now) {
+		return 0, ErrCouponNotActive
+	}
+
+	var subtotal, eligible float64
+	for _, product := range products {
+		subtotal += product.Price
+		if c.AppliesTo(product) {
+			eligible += product.Price
+		}
+	}
+
+	if subtotal < c.MinCartValue {
+		return 0, ErrCouponMinCartValue
+	}
+	if eligible == 0 {
+		return 0, ErrCouponNotApplicable
+	}
+
+	var discount float64
+	switch c.Type {
+	case CouponTypePercentage:
+		discount = eligible * c.Value / 100
+	case CouponTypeFixed:
+		discount = c.Value
+	}
+
+	return roundPrice(math.Min(discount, eligible)), nil
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
+
+	books := Category{Model: gorm.Model{ID: 2}}
+	products := []Product{
+		{Model: gorm.Model{ID: 1}, Price: 100, CategoryID: 1},
+		{Model: gorm.Model{ID: 1}, Price: 100, CategoryID: 1},
+		{Model: gorm.Model{ID: 2}, Price: 39.99, CategoryID: 2},
+	}
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		discount float64
+		err      error
+	}{
+		{
+			name:     "percentage of the whole cart",
+			coupon:   Coupon{Type: CouponTypePercentage, Value: 10},
+			discount: 24,
+		},
+		{
+			name:     "percentage of the eligible category only",
+			coupon:   Coupon{Type: CouponTypePercentage, Value: 15, Categories: []Category{books}},
+			discount: 6,
+		},
+		{
+			name:     "fixed amount",
+			coupon:   Coupon{Type: CouponTypeFixed, Value: 50},
+			discount: 50,
+		},
+		{
+			name:     "fixed amount capped at the eligible products",
+			coupon:   Coupon{Type: CouponTypeFixed, Value: 50, Categories: []Category{books}},
+			discount: 39.99,
+		},
+		{
+			name:     "minimum cart value reached",
+			coupon:   Coupon{Type: CouponTypeFixed, Value: 20, MinCartValue: 239.99},
+			discount: 20,
+		},
+		{
+			name:   "minimum cart value not reached",
+			coupon: Coupon{Type: CouponTypeFixed, Value: 20, MinCartValue: 240},
+			err:    ErrCouponMinCartValue,
+		},
+		{
+			name:   "expired",
+			coupon: Coupon{Type: CouponTypePercentage, Value: 10, ValidUntil: &yesterday},
+			err:    ErrCouponNotActive,
+		},
+		{
+			name:   "not valid yet",
+			coupon: Coupon{Type: CouponTypePercentage, Value: 10, ValidFrom: &tomorrow},
+			err:    ErrCouponNotActive,
+		},
+		{
+			name:     "within its validity period",
+			coupon:   Coupon{Type: CouponTypePercentage, Value: 10, ValidFrom: &yesterday, ValidUntil: &tomorrow},
+			discount: 24,
+		},
+		{
+			name:   "no eligible products",
+			coupon: Coupon{Type: CouponTypePercentage, Value: 10, Categories: []Category{{Model: gorm.Model{ID: 9}}}},
+			err:    ErrCouponNotApplicable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, err := tt.coupon.Discount(products, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if discount != tt.discount {
+				t.Errorf("Expected discount %.2f, got %.2f", tt.discount, discount)
+			}
+		})
+	}
+}
+
+func TestCouponCheckUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		coupon     Coupon
+		used       int64
+		usedByUser int64
+		userID     uint
+		err        error
+	}{
+		{name: "unlimited", coupon: Coupon{}, used: 1000, usedByUser: 1000, userID: 1},
+		{name: "below the usage limit", coupon: Coupon{UsageLimit: 3}, used: 2},
+		{name: "usage limit exhausted", coupon: Coupon{UsageLimit: 3}, used: 3, err: ErrCouponUsageLimit},
+		{name: "below the per-user limit", coupon: Coupon{UsageLimitPerUser: 2}, used: 10, usedByUser: 1, userID: 1},
+		{name: "per-user limit exhausted", coupon: Coupon{UsageLimitPerUser: 2}, usedByUser: 2, userID: 1, err: ErrCouponUserLimit},
+		{name: "per-user limit needs a user", coupon: Coupon{UsageLimitPerUser: 2}, err: ErrCouponRequiresUser},
+		{name: "usage limit checked first", coupon: Coupon{UsageLimit: 1, UsageLimitPerUser: 1}, used: 1, usedByUser: 1, userID: 1, err: ErrCouponUsageLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.coupon.CheckUsage(tt.used, tt.usedByUser, tt.userID); !errors.Is(err, tt.err) {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "gorm.io/gorm"
+
+type Order struct {
+	gorm.Model
+	StoreID    uint        `gorm:"index" json:"store_id"`
+	CartID     uint        `gorm:"uniqueIndex" json:"cart_id"`
+	UserID     uint        `gorm:"index" json:"user_id"`
+	CouponCode string      `json:"coupon_code"`
+	Subtotal   float64     `json:"subtotal"`
+	Discount   float64     `json:"discount"`
+	Total      float64     `json:"total"`
+	Items      []OrderItem `json:"items"`
+
+	PromotionDiscount float64 `json:"promotion_discount"`
+
+	// Copies of the addresses chosen at checkout, kept when the address
+	// book changes.
+	ShippingAddress PostalAddress `gorm:"embedded;embeddedPrefix:shipping_" json:"shipping_address"`
+	BillingAddress  PostalAddress `gorm:"embedded;embeddedPrefix:billing_" json:"billing_address"`
+
+	ShippingMethodID *uint   `json:"shipping_method_id"`
+	ShippingMethod   string  `json:"shipping_method"`
+	Shipping         float64 `json:"shipping"`
+	Weight           float64 `json:"weight"`
+
+	Region   string         `json:"region"`
+	Net      float64        `json:"net"`
+	Tax      float64        `json:"tax"`
+	TaxLines []OrderTaxLine `json:"tax_lines"`
+}
+
+type OrderItem struct {
+	gorm.Model
+	OrderID   uint    `gorm:"index" json:"order_id"`
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `gorm:"not null;default:1" json:"quantity"`
+	TaxRate   float64 `json:"tax_rate"`
+}
@@ -6,9 +6,27 @@ import (
 
 type Product struct {
 	gorm.Model
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	CategoryID  uint    `json:"category_id"`
-	Category    Category
+	StoreID       uint    `gorm:"index" json:"store_id"`
+	Name          string  `json:"name"`
+	Description   string  `json:"description"`
+	Price         float64 `json:"price"`
+	CategoryID    uint    `json:"category_id"`
+	Category      Category
+	TaxClassID    *uint   `json:"tax_class_id"`
+	AverageRating float64 `gorm:"-" json:"average_rating"`
+	ReviewCount   int64   `gorm:"-" json:"review_count"`
+
+	// Shipping weight in kg and package dimensions in cm.
+	Weight float64 `json:"weight"`
+	Length float64 `json:"length"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+
+	// Filled in per request from the tax rate of the caller's region.
+	// DisplayPrice is NetPrice or GrossPrice, as chosen by PriceMode.
+	TaxRate      float64 `gorm:"-" json:"tax_rate"`
+	NetPrice     float64 `gorm:"-" json:"net_price"`
+	GrossPrice   float64 `gorm:"-" json:"gross_price"`
+	DisplayPrice float64 `gorm:"-" json:"display_price"`
+	PriceMode    string  `gorm:"-" json:"price_mode,omitempty"`
 }
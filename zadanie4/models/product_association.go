@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ProductAssociation records how often RelatedProductID is in the same cart
+// as ProductID. Score is the cosine similarity of the two products' carts,
+// Support the number of carts holding both. The table is rebuilt from
+// cart_products by the recommendations package.
+type ProductAssociation struct {
+	StoreID          uint      `gorm:"index" json:"store_id"`
+	ProductID        uint      `gorm:"primaryKey;autoIncrement:false" json:"product_id"`
+	RelatedProductID uint      `gorm:"primaryKey;autoIncrement:false" json:"related_product_id"`
+	Score            float64   `json:"score"`
+	Support          int64     `json:"support"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PromotionTypeBuyXGetY           = "buy_x_get_y"
+	PromotionTypeCategoryPercentage = "category_percentage"
+	PromotionTypeBundle             = "bundle"
+)
+
+// Promotion is a declarative pricing rule evaluated against cart contents.
+// Which fields are used depends on Type:
+//   - buy_x_get_y: BuyQuantity, FreeQuantity and optionally CategoryID
+//   - category_percentage: Percentage, MinAmount and optionally CategoryID
+//   - bundle: Products and BundlePrice
+//
+// Promotions are evaluated by descending Priority. A promotion that is not
+// Stackable only applies when nothing else has, and stops evaluation.
+type Promotion struct {
+	gorm.Model
+	StoreID      uint       `gorm:"index" json:"store_id"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type"`
+	Priority     int        `json:"priority"`
+	Stackable    bool       `json:"stackable"`
+	Active       bool       `json:"active"`
+	ValidFrom    *time.Time `json:"valid_from"`
+	ValidUntil   *time.Time `json:"valid_until"`
+	CategoryID   *uint      `json:"category_id"`
+	BuyQuantity  int        `json:"buy_quantity"`
+	FreeQuantity int        `json:"free_quantity"`
+	Percentage   float64    `json:"percentage"`
+	MinAmount    float64    `json:"min_amount"`
+	BundlePrice  float64    `json:"bundle_price"`
+	Products     []Product  `gorm:"many2many:promotion_products;" json:"products"`
+}
+
+// AppliedPromotion explains how a promotion changed the cart total.
+type AppliedPromotion struct {
+	PromotionID uint    `json:"promotion_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Discount    float64 `json:"discount"`
+	ProductIDs  []uint  `json:"product_ids"`
+}
+
+func (p *Promotion) IsActive(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidUntil != nil && now.After(*p.ValidUntil) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+type Review struct {
+	gorm.Model
+	StoreID      uint   `gorm:"index" json:"store_id"`
+	ProductID    uint   `gorm:"index" json:"product_id"`
+	UserID       uint   `gorm:"index" json:"user_id"`
+	Rating       int    `json:"rating"`
+	Title        string `json:"title"`
+	Comment      string `json:"comment"`
+	HelpfulCount int    `json:"helpful_count"`
+	Status       string `gorm:"index;default:pending" json:"status"`
+}
+
+// ReviewVote records that a user found a review helpful. A user votes once
+// per review; HelpfulCount counts the votes.
+type ReviewVote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ReviewID  uint      `gorm:"uniqueIndex:idx_review_votes_review_user" json:"review_id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_review_votes_review_user" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func IsValidReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
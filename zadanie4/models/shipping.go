@@ -0,0 +1,52 @@
+package models
+
+import "gorm.io/gorm"
+
+// Kinds of shipping method.
+const (
+	ShippingCourier      = "courier"
+	ShippingParcelLocker = "parcel_locker"
+	ShippingPickup       = "pickup"
+)
+
+// ShippingMethod is a way of delivering an order. Its price comes from the
+// first of its Rules, by ID, that matches the cart; a method without a
+// matching rule is not offered. MaxLength, MaxWidth and MaxHeight (cm), when
+// set, limit the products it takes, e.g. to what fits a parcel locker.
+type ShippingMethod struct {
+	gorm.Model
+	StoreID   uint           `gorm:"index" json:"store_id"`
+	Code      string         `gorm:"index" json:"code"`
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind"`
+	Active    bool           `json:"active"`
+	MaxLength float64        `json:"max_length"`
+	MaxWidth  float64        `json:"max_width"`
+	MaxHeight float64        `json:"max_height"`
+	Rules     []ShippingRule `json:"rules"`
+}
+
+// ShippingRule prices a shipping method for carts to Region (any region
+// when empty) whose weight in kg and value after discounts fall within the
+// given bounds; a zero maximum has no limit. Carts worth at least FreeAbove,
+// when set, ship for free.
+type ShippingRule struct {
+	gorm.Model
+	ShippingMethodID uint    `gorm:"index" json:"shipping_method_id"`
+	Region           string  `json:"region"`
+	MinWeight        float64 `json:"min_weight"`
+	MaxWeight        float64 `json:"max_weight"`
+	MinValue         float64 `json:"min_value"`
+	MaxValue         float64 `json:"max_value"`
+	Price            float64 `json:"price"`
+	FreeAbove        float64 `json:"free_above"`
+}
+
+// ShippingQuote is the price of delivering a cart with a method.
+type ShippingQuote struct {
+	ShippingMethodID uint    `json:"shipping_method_id"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	Kind             string  `json:"kind"`
+	Price            float64 `json:"price"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+// Store is one storefront (tenant) of the deployment. Requests are routed
+// to a store by its Domain or by an X-Store header naming its Slug.
+// Products, categories, carts and orders all belong to exactly one store.
+type Store struct {
+	gorm.Model
+	Slug   string `gorm:"uniqueIndex" json:"slug"`
+	Name   string `json:"name"`
+	Domain string `gorm:"index" json:"domain"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tax classes seeded for Poland.
+const (
+	TaxClassStandard     = "standard"
+	TaxClassReduced      = "reduced"
+	TaxClassSuperReduced = "super_reduced"
+	TaxClassZero         = "zero"
+)
+
+// TaxClass groups products taxed alike, e.g. the 8% reduced VAT rate. A
+// product's class is its own TaxClassID, else its category's, else the
+// standard class. Its rate depends on the region the cart is taxed in.
+type TaxClass struct {
+	gorm.Model
+	StoreID uint      `gorm:"uniqueIndex:idx_tax_classes_store_code" json:"store_id"`
+	Code    string    `gorm:"uniqueIndex:idx_tax_classes_store_code" json:"code"`
+	Name    string    `json:"name"`
+	Rates   []TaxRate `json:"rates"`
+}
+
+// TaxRate is the percentage a tax class is charged at in a region, an ISO
+// 3166 country code such as PL.
+type TaxRate struct {
+	gorm.Model
+	TaxClassID uint    `gorm:"uniqueIndex:idx_tax_rates_class_region" json:"tax_class_id"`
+	Region     string  `gorm:"uniqueIndex:idx_tax_rates_class_region" json:"region"`
+	Rate       float64 `json:"rate"`
+}
+
+// TaxLine is the tax of all cart or order items sharing a class and rate,
+// after discounts.
+type TaxLine struct {
+	Class string  `json:"class"`
+	Rate  float64 `json:"rate"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+}
+
+// OrderTaxLine stores a TaxLine of a placed order.
+type OrderTaxLine struct {
+	gorm.Model
+	OrderID uint `gorm:"index" json:"order_id"`
+	TaxLine `gorm:"embedded"`
+}
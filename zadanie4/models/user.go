@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// User is an account of one store; the same email may sign up to several
+// stores as separate accounts.
+type User struct {
+	gorm.Model
+	StoreID      uint   `gorm:"uniqueIndex:idx_users_store_email" json:"store_id"`
+	Email        string `gorm:"uniqueIndex:idx_users_store_email" json:"email"`
+	Name         string `json:"name"`
+	Role         string `gorm:"index;default:customer" json:"role"`
+	PasswordHash string `json:"-"`
+}
+
+// Session is a bearer token issued at login. Only the SHA-256 of the token
+// is stored, so a leaked table does not leak usable tokens.
+type Session struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	User      User      `json:"-"`
+	TokenHash string    `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
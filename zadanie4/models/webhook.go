@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription sends the listed event types, or every type when
+// Events contains "*", to URL. Secret signs the deliveries and is only
+// returned when the subscription is created.
+type WebhookSubscription struct {
+	gorm.Model
+	StoreID uint     `gorm:"index" json:"store_id"`
+	URL     string   `json:"url"`
+	Events  []string `gorm:"serializer:json" json:"events"`
+	Secret  string   `json:"secret,omitempty"`
+	Active  bool     `json:"active"`
+}
+
+// Matches reports whether the subscription wants events of eventType.
+func (s WebhookSubscription) Matches(eventType string) bool {
+	for _, e := range s.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the transactional outbox: events are written in the same
+// transaction as the change they describe and fanned out to subscriptions
+// by the dispatcher, which sets DispatchedAt.
+type WebhookEvent struct {
+	ID           uint            `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	StoreID      uint            `gorm:"index" json:"store_id"`
+	Type         string          `gorm:"index" json:"type"`
+	Payload      json.RawMessage `json:"data"`
+	DispatchedAt *time.Time      `gorm:"index" json:"dispatched_at"`
+}
+
+// WebhookDelivery tracks sending one event to one subscription.
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint                     `gorm:"index" json:"subscription_id"`
+	EventID        uint                     `gorm:"index" json:"event_id"`
+	Event          WebhookEvent             `json:"event"`
+	Status         string                   `gorm:"index;default:pending" json:"status"`
+	AttemptCount   int                      `json:"attempt_count"`
+	NextAttemptAt  *time.Time               `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt    *time.Time               `json:"delivered_at"`
+	Attempts       []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"attempts"`
+}
+
+// WebhookDeliveryAttempt is one HTTP request made for a delivery.
+type WebhookDeliveryAttempt struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	DeliveryID   uint      `gorm:"index" json:"delivery_id"`
+	StatusCode   int       `json:"status_code"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	ResponseBody string    `json:"response_body,omitempty"`
+}
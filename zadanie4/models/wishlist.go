@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Wishlist struct {
+	gorm.Model
+	StoreID uint           `gorm:"index" json:"store_id"`
+	UserID  uint           `gorm:"index" json:"user_id"`
+	Name    string         `json:"name"`
+	Items   []WishlistItem `json:"items"`
+}
+
+type WishlistItem struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	WishlistID     uint      `gorm:"uniqueIndex:idx_wishlist_product" json:"wishlist_id"`
+	ProductID      uint      `gorm:"uniqueIndex:idx_wishlist_product" json:"product_id"`
+	Product        Product   `json:"product"`
+	PriceWhenAdded float64   `json:"price_when_added"`
+	CreatedAt      time.Time `json:"created_at"`
+}
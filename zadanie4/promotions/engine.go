@@ -0,0 +1,228 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"shop/models"
+)
+
+// Evaluate applies the promotions to the products and returns the ones that
+// changed the price, in the order they were applied. products holds one
+// entry per unit, so a product bought twice is listed twice. Each unit's
+// price can only be discounted down to zero across all promotions.
+func Evaluate(promotions []models.Promotion, products []models.Product, now time.Time) []models.AppliedPromotion {
+	ordered := make([]models.Promotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		if promotion.IsActive(now) {
+			ordered = append(ordered, promotion)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	// remaining is the price left on each unit, by its index in products.
+	remaining := make([]float64, len(products))
+	for i, product := range products {
+		remaining[i] = product.Price
+	}
+
+	applied := []models.AppliedPromotion{}
+	for _, promotion := range ordered {
+		if !promotion.Stackable && len(applied) > 0 {
+			continue
+		}
+
+		result, ok := evaluateRule(promotion, products, remaining)
+		if !ok || result.Discount <= 0 {
+			continue
+		}
+
+		applied = append(applied, result)
+		if !promotion.Stackable {
+			break
+		}
+	}
+
+	return applied
+}
+
+// TotalDiscount sums the discounts of the applied promotions.
+func TotalDiscount(applied []models.AppliedPromotion) float64 {
+	var total float64
+	for _, promotion := range applied {
+		total += promotion.Discount
+	}
+	return round(total)
+}
+
+func evaluateRule(promotion models.Promotion, products []models.Product, remaining []float64) (models.AppliedPromotion, bool) {
+	switch promotion.Type {
+	case models.PromotionTypeBuyXGetY:
+		return evaluateBuyXGetY(promotion, products, remaining)
+	case models.PromotionTypeCategoryPercentage:
+		return evaluateCategoryPercentage(promotion, products, remaining)
+	case models.PromotionTypeBundle:
+		return evaluateBundle(promotion, products, remaining)
+	}
+	return models.AppliedPromotion{}, false
+}
+
+// evaluateBuyXGetY makes the cheapest FreeQuantity units of every group of
+// BuyQuantity+FreeQuantity eligible units free.
+func evaluateBuyXGetY(promotion models.Promotion, products []models.Product, remaining []float64) (models.AppliedPromotion, bool) {
+	groupSize := promotion.BuyQuantity + promotion.FreeQuantity
+	if promotion.BuyQuantity < 1 || promotion.FreeQuantity < 1 {
+		return models.AppliedPromotion{}, false
+	}
+
+	eligible := eligibleUnits(promotion, products)
+	if len(eligible) < groupSize {
+		return models.AppliedPromotion{}, false
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		return products[eligible[i]].Price > products[eligible[j]].Price
+	})
+
+	var free []int
+	for start := 0; start+groupSize <= len(eligible); start += groupSize {
+		free = append(free, eligible[start+promotion.BuyQuantity:start+groupSize]...)
+	}
+
+	description := fmt.Sprintf("Buy %d get %d free", promotion.BuyQuantity, promotion.FreeQuantity)
+	return take(promotion, description, products, free, remaining, func(price float64) float64 {
+		return price
+	})
+}
+
+// evaluateCategoryPercentage takes Percentage off the eligible units once
+// their combined price reaches MinAmount.
+func evaluateCategoryPercentage(promotion models.Promotion, products []models.Product, remaining []float64) (models.AppliedPromotion, bool) {
+	if promotion.Percentage <= 0 || promotion.Percentage > 100 {
+		return models.AppliedPromotion{}, false
+	}
+
+	eligible := eligibleUnits(promotion, products)
+	var subtotal float64
+	for _, i := range eligible {
+		subtotal += products[i].Price
+	}
+	if len(eligible) == 0 || subtotal < promotion.MinAmount {
+		return models.AppliedPromotion{}, false
+	}
+
+	description := fmt.Sprintf("%.0f%% off", promotion.Percentage)
+	if promotion.MinAmount > 0 {
+		description += fmt.Sprintf(" over %.2f", promotion.MinAmount)
+	}
+	return take(promotion, description, products, eligible, remaining, func(price float64) float64 {
+		return price * promotion.Percentage / 100
+	})
+}
+
+// evaluateBundle sells the promotion's products for BundlePrice, once for
+// every complete set of them in the cart.
+func evaluateBundle(promotion models.Promotion, products []models.Product, remaining []float64) (models.AppliedPromotion, bool) {
+	if len(promotion.Products) == 0 {
+		return models.AppliedPromotion{}, false
+	}
+
+	units := make(map[uint][]int, len(products))
+	for i, product := range products {
+		units[product.ID] = append(units[product.ID], i)
+	}
+
+	sets := len(products)
+	for _, member := range promotion.Products {
+		sets = min(sets, len(units[member.ID]))
+	}
+	if sets == 0 {
+		return models.AppliedPromotion{}, false
+	}
+
+	var result models.AppliedPromotion
+	found := false
+	for set := 0; set < sets; set++ {
+		var bundle []int
+		var bundleValue float64
+		for _, member := range promotion.Products {
+			i := units[member.ID][set]
+			bundle = append(bundle, i)
+			bundleValue += remaining[i]
+		}
+		if bundleValue <= promotion.BundlePrice {
+			continue
+		}
+
+		// Spread the saving proportionally so every bundle product is reduced.
+		ratio := (bundleValue - promotion.BundlePrice) / bundleValue
+		description := fmt.Sprintf("Bundle of %d for %.2f", len(bundle), promotion.BundlePrice)
+		applied, ok := take(promotion, description, products, bundle, remaining, func(price float64) float64 {
+			return price * ratio
+		})
+		if !ok {
+			continue
+		}
+		if !found {
+			result, found = applied, true
+			continue
+		}
+		result.Discount = round(result.Discount + applied.Discount)
+		result.ProductIDs = appendMissing(result.ProductIDs, applied.ProductIDs...)
+	}
+	return result, found
+}
+
+// eligibleUnits returns the indexes of the units the promotion applies to.
+func eligibleUnits(promotion models.Promotion, products []models.Product) []int {
+	var eligible []int
+	for i, product := range products {
+		if promotion.CategoryID == nil || *promotion.CategoryID == product.CategoryID {
+			eligible = append(eligible, i)
+		}
+	}
+	return eligible
+}
+
+// take deducts discountFor(remaining price) from every listed unit and
+// records the result.
+func take(promotion models.Promotion, description string, products []models.Product, units []int, remaining []float64, discountFor func(price float64) float64) (models.AppliedPromotion, bool) {
+	result := models.AppliedPromotion{
+		PromotionID: promotion.ID,
+		Name:        promotion.Name,
+		Description: description,
+		ProductIDs:  []uint{},
+	}
+
+	for _, i := range units {
+		price := remaining[i]
+		discount := math.Min(round(discountFor(price)), price)
+		if discount <= 0 {
+			continue
+		}
+		remaining[i] = price - discount
+		result.Discount += discount
+		result.ProductIDs = appendMissing(result.ProductIDs, products[i].ID)
+	}
+
+	result.Discount = round(result.Discount)
+	return result, result.Discount > 0
+}
+
+// appendMissing appends the IDs not yet in ids.
+func appendMissing(ids []uint, more ...uint) []uint {
+	for _, id := range more {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
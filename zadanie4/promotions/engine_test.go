@@ -0,0 +1,227 @@
+package promotions
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	laptop = models.Product{Model: gorm.Model{ID: 1}, Name: "Laptop", Price: 100, CategoryID: 1}
+	mouse  = models.Product{Model: gorm.Model{ID: 2}, Name: "Mouse", Price: 50, CategoryID: 1}
+	book   = models.Product{Model: gorm.Model{ID: 3}, Name: "Book", Price: 20, CategoryID: 2}
+)
+
+// cart holds the products with the given quantities, in the order given.
+func cart(lines ...interface{}) models.Cart {
+	var c models.Cart
+	for i := 0; i < len(lines); i += 2 {
+		product := lines[i].(models.Product)
+		c.Products = append(c.Products, product)
+		c.Items = append(c.Items, models.CartItem{ProductID: product.ID, Quantity: lines[i+1].(int)})
+	}
+	return c
+}
+
+func category(id uint) *uint {
+	return &id
+}
+
+func buyXGetY(id uint, priority int, stackable bool, buy, free int, categoryID *uint) models.Promotion {
+	return models.Promotion{
+		Model: gorm.Model{ID: id}, Type: models.PromotionTypeBuyXGetY, Active: true,
+		Priority: priority, Stackable: stackable, BuyQuantity: buy, FreeQuantity: free, CategoryID: categoryID,
+	}
+}
+
+func percentage(id uint, priority int, stackable bool, percent, minAmount float64, categoryID *uint) models.Promotion {
+	return models.Promotion{
+		Model: gorm.Model{ID: id}, Type: models.PromotionTypeCategoryPercentage, Active: true,
+		Priority: priority, Stackable: stackable, Percentage: percent, MinAmount: minAmount, CategoryID: categoryID,
+	}
+}
+
+func bundle(id uint, price float64, products ...models.Product) models.Promotion {
+	return models.Promotion{
+		Model: gorm.Model{ID: id}, Type: models.PromotionTypeBundle, Active: true,
+		Stackable: true, BundlePrice: price, Products: products,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
+
+	expired := percentage(1, 0, true, 10, 0, nil)
+	expired.ValidUntil = &yesterday
+	scheduled := percentage(1, 0, true, 10, 0, nil)
+	scheduled.ValidFrom = &tomorrow
+	inactive := percentage(1, 0, true, 10, 0, nil)
+	inactive.Active = false
+
+	tests := []struct {
+		name       string
+		promotions []models.Promotion
+		cart       models.Cart
+		discount   float64
+		applied    []uint
+	}{
+		{
+			name:       "buy 2 get 1 frees the cheapest eligible unit",
+			promotions: []models.Promotion{buyXGetY(1, 0, true, 2, 1, category(1))},
+			cart:       cart(laptop, 2, mouse, 1, book, 5),
+			discount:   50,
+			applied:    []uint{1},
+		},
+		{
+			name:       "buy 2 get 1 groups units from the most expensive down",
+			promotions: []models.Promotion{buyXGetY(1, 0, true, 2, 1, nil)},
+			cart:       cart(mouse, 3, laptop, 3),
+			discount:   150,
+			applied:    []uint{1},
+		},
+		{
+			name:       "buy 2 get 1 needs a complete group",
+			promotions: []models.Promotion{buyXGetY(1, 0, true, 2, 1, nil)},
+			cart:       cart(laptop, 1, book, 1),
+		},
+		{
+			name:       "buy 1 get 1 counts the units of one product",
+			promotions: []models.Promotion{buyXGetY(1, 0, true, 1, 1, nil)},
+			cart:       cart(laptop, 2),
+			discount:   100,
+			applied:    []uint{1},
+		},
+		{
+			name:       "category percentage covers every unit of the category",
+			promotions: []models.Promotion{percentage(1, 0, true, 10, 0, category(1))},
+			cart:       cart(laptop, 2, mouse, 1, book, 1),
+			discount:   25,
+			applied:    []uint{1},
+		},
+		{
+			name:       "category percentage needs the minimum amount",
+			promotions: []models.Promotion{percentage(1, 0, true, 10, 300, category(1))},
+			cart:       cart(laptop, 2, book, 10),
+		},
+		{
+			name:       "category percentage counts quantities towards the minimum amount",
+			promotions: []models.Promotion{percentage(1, 0, true, 10, 300, category(1))},
+			cart:       cart(laptop, 3),
+			discount:   30,
+			applied:    []uint{1},
+		},
+		{
+			name:       "bundle applies once per complete set",
+			promotions: []models.Promotion{bundle(1, 100, laptop, book)},
+			cart:       cart(laptop, 3, book, 2),
+			discount:   40,
+			applied:    []uint{1},
+		},
+		{
+			name:       "bundle needs every product",
+			promotions: []models.Promotion{bundle(1, 100, laptop, book)},
+			cart:       cart(laptop, 2),
+		},
+		{
+			name:       "bundle dearer than its products saves nothing",
+			promotions: []models.Promotion{bundle(1, 200, laptop, book)},
+			cart:       cart(laptop, 1, book, 1),
+		},
+		{
+			name:       "inactive, expired and scheduled promotions are ignored",
+			promotions: []models.Promotion{inactive, expired, scheduled},
+			cart:       cart(laptop, 1),
+		},
+		{
+			name: "stackable promotions apply by priority to what is left",
+			promotions: []models.Promotion{
+				buyXGetY(2, 5, true, 1, 1, nil),
+				percentage(1, 10, true, 10, 0, nil),
+			},
+			cart:     cart(laptop, 2),
+			discount: 110,
+			applied:  []uint{1, 2},
+		},
+		{
+			name: "a non-stackable promotion stops evaluation",
+			promotions: []models.Promotion{
+				percentage(1, 10, false, 10, 0, nil),
+				buyXGetY(2, 5, true, 1, 1, nil),
+			},
+			cart:     cart(laptop, 2),
+			discount: 20,
+			applied:  []uint{1},
+		},
+		{
+			name: "a non-stackable promotion is skipped once another applied",
+			promotions: []models.Promotion{
+				percentage(1, 10, true, 10, 0, nil),
+				buyXGetY(2, 5, false, 1, 1, nil),
+			},
+			cart:     cart(laptop, 2),
+			discount: 20,
+			applied:  []uint{1},
+		},
+		{
+			name: "a non-stackable promotion that does not apply lets the next one in",
+			promotions: []models.Promotion{
+				percentage(1, 10, false, 10, 1000, nil),
+				buyXGetY(2, 5, true, 1, 1, nil),
+			},
+			cart:     cart(laptop, 2),
+			discount: 100,
+			applied:  []uint{2},
+		},
+		{
+			name: "units are never discounted below zero",
+			promotions: []models.Promotion{
+				percentage(1, 10, true, 100, 0, nil),
+				buyXGetY(2, 5, true, 1, 1, nil),
+			},
+			cart:     cart(laptop, 2),
+			discount: 200,
+			applied:  []uint{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied := Evaluate(tt.promotions, tt.cart.Units(), now)
+
+			if discount := TotalDiscount(applied); discount != tt.discount {
+				t.Errorf("Expected discount %.2f, got %.2f", tt.discount, discount)
+			}
+			ids := []uint{}
+			for _, promotion := range applied {
+				ids = append(ids, promotion.PromotionID)
+			}
+			if tt.applied == nil {
+				tt.applied = []uint{}
+			}
+			if !slices.Equal(ids, tt.applied) {
+				t.Errorf("Expected promotions %v to apply, got %v", tt.applied, ids)
+			}
+		})
+	}
+}
+
+func TestEvaluateBuyXGetYListsFreedProducts(t *testing.T) {
+	applied := Evaluate([]models.Promotion{buyXGetY(1, 0, true, 2, 1, nil)}, cart(laptop, 2, mouse, 1, book, 3).Units(), time.Now())
+	if len(applied) != 1 {
+		t.Fatalf("Expected one promotion, got %d", len(applied))
+	}
+
+	// Units by price: 100 100 50 | 20 20 20, so a mouse and a book are free.
+	if applied[0].Discount != 70 {
+		t.Errorf("Expected discount 70, got %.2f", applied[0].Discount)
+	}
+	if !slices.Equal(applied[0].ProductIDs, []uint{mouse.ID, book.ID}) {
+		t.Errorf("Expected free products %v, got %v", []uint{mouse.ID, book.ID}, applied[0].ProductIDs)
+	}
+}
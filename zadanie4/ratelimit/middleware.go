@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shop/i18n"
+	"shop/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Config describes which buckets a request is counted against. Every client
+// has one bucket for the whole API, keyed by API key when it sends a valid
+// one and by IP otherwise; routes listed in Routes get an extra bucket per
+// client. The IP is c.RealIP(), so the Echo instance needs an IPExtractor
+// that only trusts X-Forwarded-For from known proxies.
+type Config struct {
+	Store        Store
+	IP           Limit
+	APIKey       Limit
+	APIKeyHeader string
+	// ValidateAPIKey reports whether key is a valid API key and returns an
+	// ID to count it under. Keys that fail, or all keys when it is nil, are
+	// counted against the IP, so made-up keys cannot escape the limit.
+	ValidateAPIKey func(c echo.Context, key string) (id string, ok bool)
+	// Routes is keyed by "METHOD /path" using Echo route patterns, e.g.
+	// "POST /products" or "POST /carts/:id/checkout".
+	Routes map[string]Limit
+}
+
+// ParseRoutes reads per-route limits written as
+// "POST /products=10/1m,POST /carts/:id/checkout=5/1m".
+func ParseRoutes(value string) (map[string]Limit, error) {
+	routes := map[string]Limit{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route limit %q, expected \"METHOD /path=requests/period\"", entry)
+		}
+
+		parsed, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = parsed
+	}
+	return routes, nil
+}
+
+// Middleware rejects requests over their limits with 429 and Retry-After,
+// and sets RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset from the
+// bucket closest to running out.
+func Middleware(cfg Config) echo.MiddlewareFunc {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.APIKeyHeader == "" {
+		cfg.APIKeyHeader = "X-API-Key"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			now := time.Now()
+
+			client, limit := "ip:"+c.RealIP(), cfg.IP
+			if key := c.Request().Header.Get(cfg.APIKeyHeader); key != "" && cfg.ValidateAPIKey != nil {
+				if id, ok := cfg.ValidateAPIKey(c, key); ok {
+					client, limit = "key:"+id, cfg.APIKey
+				}
+			}
+
+			result, err := cfg.Store.Take(client, limit, now)
+			if err != nil {
+				logging.FromContext(c.Request().Context()).Error("rate limit store", "error", err)
+				return next(c)
+			}
+
+			route := c.Request().Method + " " + c.Path()
+			if routeLimit, ok := cfg.Routes[route]; ok {
+				routeResult, err := cfg.Store.Take(client+"|"+route, routeLimit, now)
+				if err != nil {
+					logging.FromContext(c.Request().Context()).Error("rate limit store", "error", err)
+					return next(c)
+				}
+				if result.Allowed && (!routeResult.Allowed || routeResult.Remaining < result.Remaining) {
+					result = routeResult
+				}
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				h.Set("Retry-After", ceilSeconds(result.RetryAfter))
+				return c.JSON(http.StatusTooManyRequests, logging.ErrorBody(c, i18n.CodeTooManyRequests))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
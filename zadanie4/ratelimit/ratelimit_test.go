@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recordingStore allows every request and remembers the keys taken.
+type recordingStore struct {
+	keys []string
+}
+
+func (s *recordingStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	s.keys = append(s.keys, key)
+	return Result{Allowed: true, Limit: limit.Requests, Remaining: limit.Requests - 1}, nil
+}
+
+// newServer serves GET /products behind Middleware. Only connections from
+// 10.0.0.0/8 are trusted to report the client in X-Forwarded-For, the way
+// main sets up TRUSTED_PROXIES.
+func newServer(store Store) *echo.Echo {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPFromXFFHeader(
+		echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false),
+		echo.TrustIPRange(proxies),
+	)
+	e.Use(Middleware(Config{
+		Store:  store,
+		IP:     Limit{Requests: 60, Period: time.Minute},
+		APIKey: Limit{Requests: 600, Period: time.Minute},
+		ValidateAPIKey: func(c echo.Context, key string) (string, bool) {
+			return "7", key == "valid"
+		},
+		Routes: map[string]Limit{"GET /products": {Requests: 5, Period: time.Minute}},
+	}))
+	e.GET("/products", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func TestMiddlewareKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		header map[string]string
+		key    string
+	}{
+		{
+			name:   "peer address without a key",
+			remote: "203.0.113.5:4000",
+			key:    "ip:203.0.113.5",
+		},
+		{
+			name:   "valid API key",
+			remote: "203.0.113.5:4000",
+			header: map[string]string{"X-API-Key": "valid"},
+			key:    "key:7",
+		},
+		{
+			name:   "invalid API key counts against the IP",
+			remote: "203.0.113.5:4000",
+			header: map[string]string{"X-API-Key": "made-up"},
+			key:    "ip:203.0.113.5",
+		},
+		{
+			name:   "forwarded client behind a trusted proxy",
+			remote: "10.1.2.3:4000",
+			header: map[string]string{echo.HeaderXForwardedFor: "198.51.100.7"},
+			key:    "ip:198.51.100.7",
+		},
+		{
+			name:   "forwarded header from an untrusted peer is ignored",
+			remote: "203.0.113.5:4000",
+			header: map[string]string{echo.HeaderXForwardedFor: "198.51.100.7"},
+			key:    "ip:203.0.113.5",
+		},
+		{
+			name:   "spoofed entries before the trusted proxy are ignored",
+			remote: "10.1.2.3:4000",
+			header: map[string]string{echo.HeaderXForwardedFor: "192.0.2.1, 198.51.100.7"},
+			key:    "ip:198.51.100.7",
+		},
+		{
+			name:   "valid API key wins over the forwarded IP",
+			remote: "10.1.2.3:4000",
+			header: map[string]string{echo.HeaderXForwardedFor: "198.51.100.7", "X-API-Key": "valid"},
+			key:    "key:7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingStore{}
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			req.RemoteAddr = tt.remote
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			newServer(store).ServeHTTP(rec, req)
+
+			expected := []string{tt.key, tt.key + "|GET /products"}
+			if len(store.keys) != len(expected) || store.keys[0] != expected[0] || store.keys[1] != expected[1] {
+				t.Errorf("Expected buckets %v, got %v", expected, store.keys)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+	e := newServer(NewMemoryStore())
+
+	for i := 1; i <= 6; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.RemoteAddr = "203.0.113.5:4000"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if i <= 5 {
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected request %d to pass, got %d", i, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected request %d to be limited, got %d", i, rec.Code)
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+	}
+
+	// Another client has buckets of its own.
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.RemoteAddr = "203.0.113.6:4000"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected another client to pass, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,156 @@
+// Package recommendations suggests products that are frequently bought
+// together. Compute rebuilds product_associations from the co-occurrence
+// of products in cart_products; For reads it back, topping up with
+// products from the same category when there is too little cart data.
+package recommendations
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SourceBoughtTogether = "bought_together"
+	SourceSameCategory   = "same_category"
+)
+
+// Recommendation is a product suggested for another one. Score is only set
+// for products bought together.
+type Recommendation struct {
+	Product models.Product `json:"product"`
+	Score   float64        `json:"score"`
+	Source  string         `json:"source"`
+}
+
+// computeSQL scores every pair of live products sharing at least
+// @min_support carts by the cosine similarity of their carts, keeping the
+// best @per_product related products of each.
+const computeSQL = `
+WITH items AS (
+	SELECT DISTINCT cp.cart_id, cp.product_id, p.store_id
+	FROM cart_products cp
+	JOIN products p ON p.id = cp.product_id AND p.deleted_at IS NULL
+), counts AS (
+	SELECT product_id, COUNT(*) AS carts FROM items GROUP BY product_id
+), pairs AS (
+	SELECT a.store_id, a.product_id, b.product_id AS related_product_id, COUNT(*) AS support
+	FROM items a
+	JOIN items b ON b.cart_id = a.cart_id AND b.product_id <> a.product_id
+	GROUP BY a.store_id, a.product_id, b.product_id
+	HAVING COUNT(*) >= @min_support
+), scored AS (
+	SELECT pairs.*, pairs.support / SQRT(ca.carts * cb.carts) AS score
+	FROM pairs
+	JOIN counts ca ON ca.product_id = pairs.product_id
+	JOIN counts cb ON cb.product_id = pairs.related_product_id
+), ranked AS (
+	SELECT scored.*, ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY score DESC, related_product_id) AS rank
+	FROM scored
+)
+INSERT INTO product_associations (store_id, product_id, related_product_id, score, support, updated_at)
+SELECT store_id, product_id, related_product_id, score, support, @now
+FROM ranked
+WHERE rank <= @per_product`
+
+// Compute replaces all product associations with ones scored from the
+// current carts of every store and returns how many were stored.
+func Compute(db *gorm.DB, minSupport, perProduct int) (int, error) {
+	var stored int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_associations").Error; err != nil {
+			return err
+		}
+		result := tx.Exec(computeSQL, map[string]interface{}{
+			"min_support": minSupport,
+			"per_product": perProduct,
+			"now":         time.Now(),
+		})
+		stored = result.RowsAffected
+		return result.Error
+	})
+	return int(stored), err
+}
+
+// For returns up to limit recommendations for product: the products most
+// often bought with it, then products from its category.
+func For(db *gorm.DB, product models.Product, limit int) ([]Recommendation, error) {
+	var associations []models.ProductAssociation
+	err := db.Where("product_id = ?", product.ID).
+		Order("score DESC, related_product_id").
+		Limit(limit).
+		Find(&associations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := []uint{product.ID}
+	recommendations := make([]Recommendation, 0, limit)
+	if len(associations) > 0 {
+		ids := make([]uint, len(associations))
+		for i, association := range associations {
+			ids[i] = association.RelatedProductID
+		}
+
+		var related []models.Product
+		if err := db.Preload("Category").Find(&related, ids).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[uint]models.Product, len(related))
+		for _, p := range related {
+			byID[p.ID] = p
+		}
+
+		// Products deleted since the last Compute are missing from byID.
+		for _, association := range associations {
+			if p, ok := byID[association.RelatedProductID]; ok {
+				recommendations = append(recommendations, Recommendation{Product: p, Score: association.Score, Source: SourceBoughtTogether})
+				exclude = append(exclude, p.ID)
+			}
+		}
+	}
+
+	if len(recommendations) >= limit || product.CategoryID == 0 {
+		return recommendations, nil
+	}
+
+	var sameCategory []models.Product
+	err = db.Preload("Category").
+		Where("category_id = ? AND id NOT IN ?", product.CategoryID, exclude).
+		Order("id").
+		Limit(limit - len(recommendations)).
+		Find(&sameCategory).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range sameCategory {
+		recommendations = append(recommendations, Recommendation{Product: p, Source: SourceSameCategory})
+	}
+	return recommendations, nil
+}
+
+// Start runs Compute right away and then every interval until ctx is
+// cancelled.
+func Start(ctx context.Context, db *gorm.DB, interval time.Duration, minSupport, perProduct int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := Compute(db, minSupport, perProduct)
+		if err != nil {
+			slog.Error("computing recommendations", "error", err)
+		} else {
+			slog.Debug("computed recommendations", "associations", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
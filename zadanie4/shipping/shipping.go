@@ -0,0 +1,103 @@
+// Package shipping prices the delivery of a cart. Every shipping method has
+// rules matching the cart's weight, value and destination region; the first
+// matching rule sets the price. Methods with size limits, such as parcel
+// lockers, are only offered when every product fits.
+package shipping
+
+import (
+	"math"
+	"sort"
+
+	"shop/models"
+)
+
+// Weight returns the total weight of products in kg.
+func Weight(products []models.Product) float64 {
+	var weight float64
+	for _, product := range products {
+		weight += product.Weight
+	}
+	return math.Round(weight*1000) / 1000
+}
+
+// Quote prices method for products worth value, after discounts, shipped to
+// region. It reports false when the method is inactive, a product does not
+// fit or no rule matches.
+func Quote(method models.ShippingMethod, products []models.Product, value float64, region string) (models.ShippingQuote, bool) {
+	quote := models.ShippingQuote{
+		ShippingMethodID: method.ID,
+		Code:             method.Code,
+		Name:             method.Name,
+		Kind:             method.Kind,
+	}
+	if !method.Active || !fits(method, products) {
+		return quote, false
+	}
+
+	rules := append([]models.ShippingRule(nil), method.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	weight := Weight(products)
+	for _, rule := range rules {
+		if !matches(rule, weight, value, region) {
+			continue
+		}
+		if rule.FreeAbove <= 0 || value < rule.FreeAbove {
+			quote.Price = rule.Price
+		}
+		return quote, true
+	}
+	return quote, false
+}
+
+// Quotes prices every available method, cheapest first.
+func Quotes(methods []models.ShippingMethod, products []models.Product, value float64, region string) []models.ShippingQuote {
+	quotes := []models.ShippingQuote{}
+	for _, method := range methods {
+		if quote, ok := Quote(method, products, value, region); ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Price < quotes[j].Price })
+	return quotes
+}
+
+func matches(rule models.ShippingRule, weight, value float64, region string) bool {
+	if rule.Region != "" && rule.Region != region {
+		return false
+	}
+	if weight < rule.MinWeight || (rule.MaxWeight > 0 && weight > rule.MaxWeight) {
+		return false
+	}
+	return value >= rule.MinValue && (rule.MaxValue <= 0 || value <= rule.MaxValue)
+}
+
+// fits reports whether every product fits the method's size limits, where
+// zero means no limit. The dimensions are compared sorted, so a product may
+// be turned to fit.
+func fits(method models.ShippingMethod, products []models.Product) bool {
+	limits := []float64{method.MaxLength, method.MaxWidth, method.MaxHeight}
+	limited := false
+	for i, limit := range limits {
+		if limit <= 0 {
+			limits[i] = math.Inf(1)
+		} else {
+			limited = true
+		}
+	}
+	if !limited {
+		return true
+	}
+	sort.Float64s(limits)
+
+	for _, product := range products {
+		size := []float64{product.Length, product.Width, product.Height}
+		sort.Float64s(size)
+		for i := range size {
+			if size[i] > limits[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
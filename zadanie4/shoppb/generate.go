@@ -0,0 +1,4 @@
+// Package shoppb holds the Go code generated from proto/shop.proto.
+package shoppb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=shop --go-grpc_out=.. --go-grpc_opt=module=shop ../proto/shop.proto
@@ -0,0 +1,188 @@
+// Package tax works out VAT for products and carts. Every product has a
+// tax class (its own, its category's or the standard one) whose rate
+// depends on the region. Catalogue prices either include the tax (gross,
+// the default for consumer shops in Poland) or exclude it (net).
+package tax
+
+import (
+	"math"
+	"sort"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+// Price display modes.
+const (
+	ModeGross = "gross"
+	ModeNet   = "net"
+)
+
+// ShippingClass names the tax line of a cart's shipping cost.
+const ShippingClass = "shipping"
+
+// DefaultRates are the Polish VAT rates seeded for the default region.
+var DefaultRates = map[string]float64{
+	models.TaxClassStandard:     23,
+	models.TaxClassReduced:      8,
+	models.TaxClassSuperReduced: 5,
+	models.TaxClassZero:         0,
+}
+
+// Config says how catalogue prices are taxed.
+type Config struct {
+	// Region is used for carts and requests that name none, and for tax
+	// classes without a rate in the requested region.
+	Region string
+	// PricesIncludeTax is true when catalogue prices are gross.
+	PricesIncludeTax bool
+	// DisplayMode is the default of ModeGross and ModeNet for product prices.
+	DisplayMode string
+}
+
+// Table holds the rates of every tax class.
+type Table struct {
+	config  Config
+	classes map[uint]models.TaxClass
+	// fallback is the standard class, used for products without one.
+	fallback models.TaxClass
+}
+
+// Load reads the tax classes with their rates.
+func Load(db *gorm.DB, config Config) (*Table, error) {
+	var classes []models.TaxClass
+	if err := db.Preload("Rates").Find(&classes).Error; err != nil {
+		return nil, err
+	}
+
+	t := &Table{config: config, classes: make(map[uint]models.TaxClass, len(classes))}
+	for _, class := range classes {
+		t.classes[class.ID] = class
+		if class.Code == models.TaxClassStandard {
+			t.fallback = class
+		}
+	}
+	return t, nil
+}
+
+// Config returns the configuration the table was loaded with.
+func (t *Table) Config() Config {
+	return t.config
+}
+
+// ClassOf returns the tax class of product, whose Category must be loaded
+// for the category's class to be used.
+func (t *Table) ClassOf(product models.Product) models.TaxClass {
+	for _, id := range []*uint{product.TaxClassID, product.Category.TaxClassID} {
+		if id == nil {
+			continue
+		}
+		if class, ok := t.classes[*id]; ok {
+			return class
+		}
+	}
+	return t.fallback
+}
+
+// Rate returns the percentage class is charged at in region, falling back
+// to the default region. An unknown class is not taxed.
+func (t *Table) Rate(class models.TaxClass, region string) float64 {
+	var fallback float64
+	for _, rate := range class.Rates {
+		if rate.Region == region {
+			return rate.Rate
+		}
+		if rate.Region == t.config.Region {
+			fallback = rate.Rate
+		}
+	}
+	return fallback
+}
+
+// Split returns the net, tax and gross parts of a catalogue amount taxed at
+// rate percent.
+func (t *Table) Split(amount, rate float64) (net, tax, gross float64) {
+	if t.config.PricesIncludeTax {
+		gross = round(amount)
+		net = round(amount * 100 / (100 + rate))
+		return net, round(gross - net), gross
+	}
+	net = round(amount)
+	tax = round(amount * rate / 100)
+	return net, tax, round(net + tax)
+}
+
+// ApplyPrices sets the tax rate and the net, gross and display prices of
+// products for region. mode is ModeNet or ModeGross; anything else uses
+// the configured display mode.
+func (t *Table) ApplyPrices(products []models.Product, region, mode string) {
+	if mode != ModeNet && mode != ModeGross {
+		mode = t.config.DisplayMode
+	}
+	for i := range products {
+		p := &products[i]
+		p.TaxRate = t.Rate(t.ClassOf(*p), region)
+		p.NetPrice, _, p.GrossPrice = t.Split(p.Price, p.TaxRate)
+		p.PriceMode = mode
+		p.DisplayPrice = p.GrossPrice
+		if mode == ModeNet {
+			p.DisplayPrice = p.NetPrice
+		}
+	}
+}
+
+// Lines groups products by tax class and rate and taxes each group after
+// its share of discount, which is spread over the groups in proportion to
+// their value. Lines are ordered by descending rate.
+func (t *Table) Lines(products []models.Product, region string, discount float64) []models.TaxLine {
+	type key struct {
+		class string
+		rate  float64
+	}
+	amounts := map[key]float64{}
+	var subtotal float64
+	for _, product := range products {
+		class := t.ClassOf(product)
+		amounts[key{class.Code, t.Rate(class, region)}] += product.Price
+		subtotal += product.Price
+	}
+
+	lines := make([]models.TaxLine, 0, len(amounts))
+	for k, amount := range amounts {
+		if subtotal > 0 {
+			amount -= discount * amount / subtotal
+		}
+		line := models.TaxLine{Class: k.class, Rate: k.rate}
+		line.Net, line.Tax, line.Gross = t.Split(amount, k.rate)
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Rate != lines[j].Rate {
+			return lines[i].Rate > lines[j].Rate
+		}
+		return lines[i].Class < lines[j].Class
+	})
+	return lines
+}
+
+// ShippingLine taxes a shipping price at the standard rate of region.
+func (t *Table) ShippingLine(amount float64, region string) models.TaxLine {
+	line := models.TaxLine{Class: ShippingClass, Rate: t.Rate(t.fallback, region)}
+	line.Net, line.Tax, line.Gross = t.Split(amount, line.Rate)
+	return line
+}
+
+// Totals sums the net, tax and gross amounts of lines.
+func Totals(lines []models.TaxLine) (net, tax, gross float64) {
+	for _, line := range lines {
+		net += line.Net
+		tax += line.Tax
+		gross += line.Gross
+	}
+	return round(net), round(tax), round(gross)
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
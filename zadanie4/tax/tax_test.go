@@ -0,0 +1,234 @@
+package tax
+
+import (
+	"testing"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	standard = models.TaxClass{Model: gorm.Model{ID: 1}, Code: models.TaxClassStandard, Rates: []models.TaxRate{
+		{Region: "PL", Rate: 23},
+		{Region: "DE", Rate: 19},
+	}}
+	reduced = models.TaxClass{Model: gorm.Model{ID: 2}, Code: models.TaxClassReduced, Rates: []models.TaxRate{
+		{Region: "PL", Rate: 8},
+	}}
+	exportOnly = models.TaxClass{Model: gorm.Model{ID: 3}, Code: "export", Rates: []models.TaxRate{
+		{Region: "DE", Rate: 7},
+	}}
+)
+
+// table is what Load returns for the classes above.
+func table(pricesIncludeTax bool) *Table {
+	return &Table{
+		config:   Config{Region: "PL", PricesIncludeTax: pricesIncludeTax, DisplayMode: ModeGross},
+		classes:  map[uint]models.TaxClass{1: standard, 2: reduced, 3: exportOnly},
+		fallback: standard,
+	}
+}
+
+func class(id uint) *uint {
+	return &id
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		class  models.TaxClass
+		region string
+		rate   float64
+	}{
+		{"rate of the region", standard, "DE", 19},
+		{"rate of the default region", standard, "PL", 23},
+		{"falls back to the default region", standard, "CZ", 23},
+		{"falls back when the region has no rate for the class", reduced, "DE", 8},
+		{"no rate in the region or the default region", exportOnly, "CZ", 0},
+		{"unknown class", models.TaxClass{Code: "unknown"}, "PL", 0},
+	}
+
+	tbl := table(true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rate := tbl.Rate(tt.class, tt.region); rate != tt.rate {
+				t.Errorf("Expected rate %v, got %v", tt.rate, rate)
+			}
+		})
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		product models.Product
+		class   string
+	}{
+		{"own class", models.Product{TaxClassID: class(2)}, models.TaxClassReduced},
+		{"category's class", models.Product{Category: models.Category{TaxClassID: class(2)}}, models.TaxClassReduced},
+		{"own class over the category's", models.Product{TaxClassID: class(3), Category: models.Category{TaxClassID: class(2)}}, "export"},
+		{"unknown own class uses the category's", models.Product{TaxClassID: class(99), Category: models.Category{TaxClassID: class(2)}}, models.TaxClassReduced},
+		{"standard class otherwise", models.Product{}, models.TaxClassStandard},
+	}
+
+	tbl := table(true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if class := tbl.ClassOf(tt.product); class.Code != tt.class {
+				t.Errorf("Expected class %s, got %s", tt.class, class.Code)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name             string
+		pricesIncludeTax bool
+		amount, rate     float64
+		net, tax, gross  float64
+	}{
+		{"gross price", true, 123, 23, 100, 23, 123},
+		{"gross price rounds the net part", true, 10, 23, 8.13, 1.87, 10},
+		{"gross price at a reduced rate", true, 0.99, 8, 0.92, 0.07, 0.99},
+		{"gross price untaxed", true, 49.99, 0, 49.99, 0, 49.99},
+		{"net price", false, 100, 23, 100, 23, 123},
+		{"net price rounds the tax", false, 8.13, 23, 8.13, 1.87, 10},
+		{"net price rounds half up", false, 0.05, 23, 0.05, 0.01, 0.06},
+		{"net price rounds the amount", false, 10.005, 0, 10.01, 0, 10.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net, tax, gross := table(tt.pricesIncludeTax).Split(tt.amount, tt.rate)
+			if net != tt.net || tax != tt.tax || gross != tt.gross {
+				t.Errorf("Expected %v/%v/%v, got %v/%v/%v", tt.net, tt.tax, tt.gross, net, tax, gross)
+			}
+			if round(net+tax) != gross {
+				t.Errorf("Expected net %v and tax %v to add up to gross %v", net, tax, gross)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	laptop := models.Product{Price: 123, TaxClassID: class(1)}
+	mouse := models.Product{Price: 61.5}
+	book := models.Product{Price: 108, Category: models.Category{TaxClassID: class(2)}}
+
+	tests := []struct {
+		name             string
+		pricesIncludeTax bool
+		products         []models.Product
+		region           string
+		discount         float64
+		lines            []models.TaxLine
+	}{
+		{
+			name:             "groups by class and orders by rate",
+			pricesIncludeTax: true,
+			products:         []models.Product{book, laptop, mouse},
+			region:           "PL",
+			lines: []models.TaxLine{
+				{Class: models.TaxClassStandard, Rate: 23, Net: 150, Tax: 34.5, Gross: 184.5},
+				{Class: models.TaxClassReduced, Rate: 8, Net: 100, Tax: 8, Gross: 108},
+			},
+		},
+		{
+			name:             "spreads the discount in proportion to value",
+			pricesIncludeTax: true,
+			products:         []models.Product{laptop, mouse, book},
+			region:           "PL",
+			discount:         29.25,
+			lines: []models.TaxLine{
+				{Class: models.TaxClassStandard, Rate: 23, Net: 135, Tax: 31.05, Gross: 166.05},
+				{Class: models.TaxClassReduced, Rate: 8, Net: 90, Tax: 7.2, Gross: 97.2},
+			},
+		},
+		{
+			name:     "spreads the discount over net prices",
+			products: []models.Product{{Price: 100}, {Price: 50, TaxClassID: class(2)}},
+			region:   "PL",
+			discount: 15,
+			lines: []models.TaxLine{
+				{Class: models.TaxClassStandard, Rate: 23, Net: 90, Tax: 20.7, Gross: 110.7},
+				{Class: models.TaxClassReduced, Rate: 8, Net: 45, Tax: 3.6, Gross: 48.6},
+			},
+		},
+		{
+			name:             "uses the rates of the region with fallbacks",
+			pricesIncludeTax: true,
+			products:         []models.Product{laptop, book},
+			region:           "DE",
+			lines: []models.TaxLine{
+				{Class: models.TaxClassStandard, Rate: 19, Net: 103.36, Tax: 19.64, Gross: 123},
+				{Class: models.TaxClassReduced, Rate: 8, Net: 100, Tax: 8, Gross: 108},
+			},
+		},
+		{
+			name:     "no products",
+			region:   "PL",
+			discount: 10,
+			lines:    []models.TaxLine{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := table(tt.pricesIncludeTax).Lines(tt.products, tt.region, tt.discount)
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("Expected %d lines, got %+v", len(tt.lines), lines)
+			}
+			for i := range lines {
+				if lines[i] != tt.lines[i] {
+					t.Errorf("Expected line %+v, got %+v", tt.lines[i], lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLinesDiscountKeepsTotals(t *testing.T) {
+	products := []models.Product{
+		{Price: 19.99},
+		{Price: 5.49, TaxClassID: class(2)},
+		{Price: 7.33, TaxClassID: class(2)},
+	}
+	subtotal, discount := 32.81, 3.33
+
+	lines := table(true).Lines(products, "PL", discount)
+	net, tax, gross := Totals(lines)
+	if gross != round(subtotal-discount) {
+		t.Errorf("Expected gross total %v, got %v", round(subtotal-discount), gross)
+	}
+	if round(net+tax) != gross {
+		t.Errorf("Expected net %v and tax %v to add up to gross %v", net, tax, gross)
+	}
+}
+
+func TestShippingLine(t *testing.T) {
+	line := table(true).ShippingLine(12.3, "CZ")
+	expected := models.TaxLine{Class: ShippingClass, Rate: 23, Net: 10, Tax: 2.3, Gross: 12.3}
+	if line != expected {
+		t.Errorf("Expected %+v, got %+v", expected, line)
+	}
+}
+
+func TestApplyPrices(t *testing.T) {
+	products := []models.Product{{Price: 123}, {Price: 108, TaxClassID: class(2)}}
+
+	tbl := table(true)
+	tbl.ApplyPrices(products, "PL", ModeNet)
+	if products[0].TaxRate != 23 || products[0].NetPrice != 100 || products[0].GrossPrice != 123 || products[0].DisplayPrice != 100 {
+		t.Errorf("Expected a net display price of 100 at 23%%, got %+v", products[0])
+	}
+	if products[1].TaxRate != 8 || products[1].DisplayPrice != 100 || products[1].PriceMode != ModeNet {
+		t.Errorf("Expected a net display price of 100 at 8%%, got %+v", products[1])
+	}
+
+	tbl.ApplyPrices(products, "PL", "")
+	if products[0].DisplayPrice != 123 || products[0].PriceMode != ModeGross {
+		t.Errorf("Expected the configured gross display price, got %+v", products[0])
+	}
+}
@@ -0,0 +1,126 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"shop/cache"
+	"shop/i18n"
+	"shop/logging"
+	"shop/models"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// Header selects a store by slug, overriding the host name.
+const Header = "X-Store"
+
+// ErrUnknownStore is returned when the X-Store header names no store.
+var ErrUnknownStore = errors.New("tenant: unknown store")
+
+// stores caches lookups by slug and domain; store changes are rare and
+// call Invalidate.
+var stores = cache.New[string, *models.Store](256, time.Minute)
+
+// Invalidate forgets cached store lookups after a store is changed.
+func Invalidate() {
+	stores.Purge()
+}
+
+// Resolve finds the store a request is for: the one named by slug if
+// given, else the one whose domain is host, else the fallback store. It
+// returns nil when none of them exists.
+func Resolve(db *gorm.DB, slug, host, fallback string) (*models.Store, error) {
+	if slug != "" {
+		store, err := lookup(db, "slug", slug)
+		if err == nil && store == nil {
+			err = ErrUnknownStore
+		}
+		return store, err
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host = strings.ToLower(host); host != "" {
+		if store, err := lookup(db, "domain", host); store != nil || err != nil {
+			return store, err
+		}
+	}
+
+	return lookup(db, "slug", fallback)
+}
+
+func lookup(db *gorm.DB, column, value string) (*models.Store, error) {
+	store, _, err := stores.Load(column+":"+value, func() (*models.Store, error) {
+		var store models.Store
+		err := db.Where(column+" = ?", value).First(&store).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &store, nil
+	})
+	return store, err
+}
+
+// Middleware resolves the store of every request from the X-Store header
+// or the Host header and limits the request's statements to it. Requests
+// for hosts without a store of their own go to the fallback store.
+func Middleware(db *gorm.DB, fallback string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			store, err := Resolve(db.WithContext(ctx), c.Request().Header.Get(Header), c.Request().Host, fallback)
+			if errors.Is(err, ErrUnknownStore) {
+				return c.JSON(http.StatusNotFound, logging.ErrorBody(c, i18n.CodeStoreNotFound))
+			}
+			if err != nil {
+				return err
+			}
+			if store != nil {
+				c.SetRequest(c.Request().WithContext(WithStore(ctx, *store)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// UnaryServerInterceptor resolves the store of gRPC calls from "x-store"
+// metadata or the :authority pseudo-header, like Middleware.
+func UnaryServerInterceptor(db *gorm.DB, fallback string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var slug, host string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-store"); len(values) > 0 {
+				slug = values[0]
+			}
+			if values := md.Get(":authority"); len(values) > 0 {
+				host = values[0]
+			}
+		}
+
+		store, err := Resolve(db.WithContext(ctx), slug, host, fallback)
+		if errors.Is(err, ErrUnknownStore) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, "resolving store failed")
+		}
+		if store != nil {
+			ctx = WithStore(ctx, *store)
+		}
+		return handler(ctx, req)
+	}
+}
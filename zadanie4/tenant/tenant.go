@@ -0,0 +1,174 @@
+// Package tenant keeps the data of every store in the deployment apart.
+// Register installs GORM callbacks that limit queries, updates and deletes
+// of models with a StoreID field to the store carried by the statement's
+// context, and stamp that store on the rows being saved. A statement on
+// such a model without a store fails with ErrNoStore instead of seeing
+// every store's rows. Raw SQL cannot be scoped, so Raw and Exec on such a
+// model fail with ErrRawSQL unless they run across all stores; raw SQL
+// without a model is not checked and must filter by store_id itself.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoStore is returned for statements on store-scoped models made
+// without a store in their context.
+var ErrNoStore = errors.New("tenant: no store selected")
+
+// ErrRawSQL is returned for raw SQL on store-scoped models outside of
+// AllStores, which the callbacks cannot limit to a store.
+var ErrRawSQL = errors.New("tenant: raw SQL cannot be limited to a store")
+
+// DefaultSlug is the slug of the store created for existing data and
+// serving hosts that have no store of their own, unless configured otherwise.
+const DefaultSlug = "default"
+
+const (
+	storeField  = "StoreID"
+	storeColumn = "store_id"
+)
+
+type storeKey struct{}
+
+type allStoresKey struct{}
+
+// WithStore returns a copy of ctx whose statements are limited to store.
+func WithStore(ctx context.Context, store models.Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// FromContext returns the store the context is limited to.
+func FromContext(ctx context.Context) (models.Store, bool) {
+	store, ok := ctx.Value(storeKey{}).(models.Store)
+	return store, ok
+}
+
+// ID returns the ID of the store the context is limited to, or 0.
+func ID(ctx context.Context) uint {
+	store, _ := FromContext(ctx)
+	return store.ID
+}
+
+// AllStores returns a copy of ctx whose statements see every store. It is
+// meant for maintenance jobs such as cart expiry; rows they create must
+// have StoreID set explicitly.
+func AllStores(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allStoresKey{}, true)
+}
+
+// Register installs the tenant callbacks on db.
+func Register(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tenant:create", stamp),
+		cb.Query().Before("gorm:query").Register("tenant:query", scope),
+		cb.Update().Before("gorm:update").Register("tenant:update", func(db *gorm.DB) {
+			stamp(db)
+			scope(db)
+		}),
+		cb.Delete().Before("gorm:delete").Register("tenant:delete", scope),
+		cb.Row().Before("gorm:row").Register("tenant:row", scope),
+		cb.Raw().Before("gorm:raw").Register("tenant:raw", scope),
+	)
+}
+
+// storeFor returns the store a statement on a store-scoped model is limited
+// to. ok is false when the statement needs no scoping, either because the
+// model has no StoreID or because it runs across all stores, and when it
+// cannot be scoped, in which case an error is added.
+func storeFor(db *gorm.DB) (store models.Store, ok bool) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.LookUpField(storeField) == nil {
+		return store, false
+	}
+	if all, _ := stmt.Context.Value(allStoresKey{}).(bool); all {
+		return store, false
+	}
+	if stmt.SQL.Len() > 0 {
+		db.AddError(ErrRawSQL)
+		return store, false
+	}
+	store, ok = FromContext(stmt.Context)
+	if !ok {
+		db.AddError(ErrNoStore)
+	}
+	return store, ok
+}
+
+func scope(db *gorm.DB) {
+	store, ok := storeFor(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: storeColumn}, Value: store.ID},
+	}})
+}
+
+// stamp sets StoreID on the rows being written, so neither a request body
+// nor an upsert can move a row into another store.
+func stamp(db *gorm.DB) {
+	store, ok := storeFor(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	field := stmt.Schema.LookUpField(storeField)
+	set := func(rv reflect.Value) {
+		if rv.Kind() == reflect.Struct {
+			if err := field.Set(stmt.Context, rv, store.ID); err != nil {
+				db.AddError(err)
+			}
+		}
+	}
+	switch rv := stmt.ReflectValue; rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	default:
+		set(rv)
+	}
+
+	// Save falls back to an upsert when the update matched nothing; the
+	// conflicting row may belong to another store and must not be touched.
+	if c, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: stmt.Table, Name: storeColumn},
+				Value:  store.ID,
+			})
+			c.Expression = onConflict
+			stmt.Clauses["ON CONFLICT"] = c
+		}
+	}
+}
+
+// EnsureDefault creates the store with the given slug unless it exists and
+// moves rows of the tenanted models that predate multi-tenancy into it.
+func EnsureDefault(db *gorm.DB, slug string, tenanted ...interface{}) (models.Store, error) {
+	db = db.WithContext(AllStores(db.Statement.Context))
+
+	store := models.Store{Slug: slug, Name: slug}
+	if err := db.Where(models.Store{Slug: slug}).FirstOrCreate(&store).Error; err != nil {
+		return store, err
+	}
+	for _, model := range tenanted {
+		err := db.Model(model).
+			Where("store_id = 0 OR store_id IS NULL").
+			UpdateColumn(storeColumn, store.ID).Error
+		if err != nil {
+			return store, err
+		}
+	}
+	return store, nil
+}
@@ -0,0 +1,249 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"shop/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// item is a store-scoped model; note is not.
+type item struct {
+	ID      uint
+	StoreID uint
+	Name    string
+}
+
+type note struct {
+	ID   uint
+	Text string
+}
+
+// setup opens a fresh database with the callbacks registered and an item
+// named after each of two stores.
+func setup(t *testing.T) (db *gorm.DB, first, second context.Context) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&item{}, &note{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	first = WithStore(context.Background(), models.Store{Model: gorm.Model{ID: 1}, Slug: "first"})
+	second = WithStore(context.Background(), models.Store{Model: gorm.Model{ID: 2}, Slug: "second"})
+	for _, ctx := range []context.Context{first, second} {
+		store, _ := FromContext(ctx)
+		if err := db.WithContext(ctx).Create(&item{Name: store.Slug}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, first, second
+}
+
+func TestCreateStampsStore(t *testing.T) {
+	db, first, _ := setup(t)
+
+	created := item{StoreID: 2, Name: "forged"}
+	if err := db.WithContext(first).Create(&created).Error; err != nil {
+		t.Fatal(err)
+	}
+	if created.StoreID != 1 {
+		t.Errorf("Expected StoreID 1, got %d", created.StoreID)
+	}
+
+	batch := []item{{Name: "a", StoreID: 2}, {Name: "b"}}
+	if err := db.WithContext(first).Create(&batch).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, created := range batch {
+		if created.StoreID != 1 {
+			t.Errorf("Expected StoreID 1 for %q, got %d", created.Name, created.StoreID)
+		}
+	}
+}
+
+func TestQueryIsScoped(t *testing.T) {
+	db, first, second := setup(t)
+
+	var items []item
+	if err := db.WithContext(first).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != "first" {
+		t.Errorf("Expected only the first store's item, got %+v", items)
+	}
+
+	var other item
+	err := db.WithContext(first).Where("name = ?", "second").First(&other).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected the second store's item to be hidden, got %v", err)
+	}
+
+	var count int64
+	if err := db.WithContext(second).Model(&item{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected a count of 1, got %d", count)
+	}
+
+	var names []string
+	if err := db.WithContext(second).Model(&item{}).Pluck("name", &names).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "second" {
+		t.Errorf("Expected only the second store's name, got %v", names)
+	}
+}
+
+func TestWritesToOtherStoreAffectNothing(t *testing.T) {
+	db, first, _ := setup(t)
+
+	var target item
+	if err := db.WithContext(AllStores(context.Background())).Where("name = ?", "second").First(&target).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result := db.WithContext(first).Model(&item{}).Where("id = ?", target.ID).Update("name", "taken")
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.RowsAffected != 0 {
+		t.Errorf("Expected update to affect 0 rows, got %d", result.RowsAffected)
+	}
+
+	result = db.WithContext(first).Delete(&item{}, target.ID)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.RowsAffected != 0 {
+		t.Errorf("Expected delete to affect 0 rows, got %d", result.RowsAffected)
+	}
+
+	// An upsert on another store's primary key must not take the row over.
+	moved := item{ID: target.ID, StoreID: 2, Name: "moved"}
+	if err := db.WithContext(first).Clauses(clause.OnConflict{UpdateAll: true}).Create(&moved).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var after item
+	if err := db.WithContext(AllStores(context.Background())).First(&after, target.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if after.Name != "second" || after.StoreID != 2 {
+		t.Errorf("Expected the second store's item to be untouched, got %+v", after)
+	}
+}
+
+func TestUpdateKeepsStore(t *testing.T) {
+	db, first, _ := setup(t)
+
+	var own item
+	if err := db.WithContext(first).First(&own).Error; err != nil {
+		t.Fatal(err)
+	}
+	own.StoreID = 2
+	own.Name = "renamed"
+	if err := db.WithContext(first).Save(&own).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var after item
+	if err := db.WithContext(AllStores(context.Background())).First(&after, own.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if after.StoreID != 1 || after.Name != "renamed" {
+		t.Errorf("Expected the item to stay in the first store, got %+v", after)
+	}
+}
+
+func TestNoStore(t *testing.T) {
+	db, _, _ := setup(t)
+	ctx := context.Background()
+
+	var items []item
+	if err := db.WithContext(ctx).Find(&items).Error; !errors.Is(err, ErrNoStore) {
+		t.Errorf("Expected ErrNoStore for a query, got %v", err)
+	}
+	if err := db.WithContext(ctx).Create(&item{Name: "orphan"}).Error; !errors.Is(err, ErrNoStore) {
+		t.Errorf("Expected ErrNoStore for a create, got %v", err)
+	}
+	if err := db.WithContext(ctx).Model(&item{}).Where("1 = 1").Update("name", "x").Error; !errors.Is(err, ErrNoStore) {
+		t.Errorf("Expected ErrNoStore for an update, got %v", err)
+	}
+	if err := db.WithContext(ctx).Where("1 = 1").Delete(&item{}).Error; !errors.Is(err, ErrNoStore) {
+		t.Errorf("Expected ErrNoStore for a delete, got %v", err)
+	}
+
+	// Models without a StoreID need no store.
+	if err := db.WithContext(ctx).Create(&note{Text: "shared"}).Error; err != nil {
+		t.Errorf("Expected no error for an unscoped model, got %v", err)
+	}
+}
+
+func TestRawSQL(t *testing.T) {
+	db, first, _ := setup(t)
+
+	err := db.WithContext(first).Model(&item{}).Exec("UPDATE items SET name = ?", "all").Error
+	if !errors.Is(err, ErrRawSQL) {
+		t.Errorf("Expected ErrRawSQL for Exec, got %v", err)
+	}
+
+	var items []item
+	err = db.WithContext(first).Model(&item{}).Raw("SELECT * FROM items").Scan(&items).Error
+	if !errors.Is(err, ErrRawSQL) {
+		t.Errorf("Expected ErrRawSQL for Raw, got %v", err)
+	}
+
+	var count int64
+	if err := db.WithContext(AllStores(context.Background())).Model(&item{}).Where("name = ?", "all").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the rejected Exec to change nothing, got %d rows", count)
+	}
+
+	err = db.WithContext(AllStores(first)).Model(&item{}).Exec("UPDATE items SET name = name").Error
+	if err != nil {
+		t.Errorf("Expected raw SQL across all stores to run, got %v", err)
+	}
+}
+
+func TestAllStores(t *testing.T) {
+	db, first, _ := setup(t)
+
+	var items []item
+	if err := db.WithContext(AllStores(context.Background())).Order("store_id").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].StoreID != 1 || items[1].StoreID != 2 {
+		t.Errorf("Expected the items of both stores, got %+v", items)
+	}
+
+	created := item{StoreID: 2, Name: "maintenance"}
+	if err := db.WithContext(AllStores(first)).Create(&created).Error; err != nil {
+		t.Fatal(err)
+	}
+	if created.StoreID != 2 {
+		t.Errorf("Expected an explicit StoreID to be kept across all stores, got %d", created.StoreID)
+	}
+}
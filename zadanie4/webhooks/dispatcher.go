@@ -0,0 +1,290 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret. Receivers should
+// recompute it and reject stale timestamps to prevent replays.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const maxResponseBody = 1024
+
+// Dispatcher fans outbox events out to subscriptions of the same store and
+// sends the resulting deliveries. Deliveries are claimed before sending, so
+// several dispatchers can share a database without sending one attempt
+// twice. It serves every store, so Start and Run need a context from
+// tenant.AllStores.
+type Dispatcher struct {
+	DB     *gorm.DB
+	Client *http.Client
+	// MaxAttempts is how many times a delivery is tried before it is
+	// marked failed.
+	MaxAttempts int
+	// Retries wait BaseBackoff, then twice as long after every further
+	// failure, up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	BatchSize   int
+}
+
+// Envelope is the JSON body POSTed to subscribers.
+type Envelope struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait after the given number of failed
+// attempts.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
+
+// Start runs the dispatcher every interval until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Run(ctx); err != nil {
+				slog.Error("dispatching webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// Run fans out pending events and sends the deliveries that are due.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliverDue(ctx)
+}
+
+func (d *Dispatcher) batchSize() int {
+	if d.BatchSize > 0 {
+		return d.BatchSize
+	}
+	return 100
+}
+
+// fanOut creates a delivery per matching active subscription of the
+// event's store for every event not yet dispatched.
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	db := d.DB.WithContext(ctx)
+
+	var events []models.WebhookEvent
+	if err := db.Where("dispatched_at IS NULL").Order("id").Limit(d.batchSize()).Find(&events).Error; err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			claimed := tx.Model(&models.WebhookEvent{}).
+				Where("id = ? AND dispatched_at IS NULL", event.ID).
+				Update("dispatched_at", now)
+			if claimed.Error != nil || claimed.RowsAffected == 0 {
+				return claimed.Error
+			}
+
+			for _, subscription := range subscriptions {
+				if subscription.StoreID != event.StoreID || !subscription.Matches(event.Type) {
+					continue
+				}
+				delivery := models.WebhookDelivery{
+					SubscriptionID: subscription.ID,
+					EventID:        event.ID,
+					Status:         models.WebhookDeliveryPending,
+					NextAttemptAt:  &now,
+				}
+				if err := tx.Create(&delivery).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverDue sends every pending delivery whose next attempt is due.
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	db := d.DB.WithContext(ctx)
+
+	var deliveries []models.WebhookDelivery
+	err := db.Preload("Event").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(d.batchSize()).
+		Find(&deliveries).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if err := d.deliver(ctx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver claims a delivery by pushing its next attempt past the request
+// timeout, sends it and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	db := d.DB.WithContext(ctx)
+
+	lease := time.Now().Add(d.Client.Timeout + time.Minute)
+	claimed := db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if claimed.Error != nil || claimed.RowsAffected == 0 {
+		return claimed.Error
+	}
+
+	var subscription models.WebhookSubscription
+	attempt := models.WebhookDeliveryAttempt{DeliveryID: delivery.ID}
+	if err := db.First(&subscription, delivery.SubscriptionID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		attempt.Error = "subscription no longer exists"
+	} else if !subscription.Active {
+		attempt.Error = "subscription is inactive"
+	} else {
+		d.send(ctx, subscription, delivery, &attempt)
+	}
+
+	now := time.Now()
+	delivery.AttemptCount++
+	switch {
+	case attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.AttemptCount >= d.MaxAttempts || subscription.ID == 0 || !subscription.Active:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(Backoff(delivery.AttemptCount, d.BaseBackoff, d.MaxBackoff))
+		delivery.NextAttemptAt = &next
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Select("status", "attempt_count", "next_attempt_at", "delivered_at").Updates(delivery).Error
+	})
+}
+
+// send POSTs the delivery's event and fills in the attempt.
+func (d *Dispatcher) send(ctx context.Context, subscription models.WebhookSubscription, delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt) {
+	body, err := json.Marshal(Envelope{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shop-webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.Client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.ResponseBody = string(snippet)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// Redeliver queues a delivery to be sent again on the next run, whatever
+// its current status. Earlier attempts stay in its log.
+func Redeliver(db *gorm.DB, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.AttemptCount = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+	return db.Model(delivery).Select("status", "attempt_count", "next_attempt_at", "delivered_at").Updates(delivery).Error
+}
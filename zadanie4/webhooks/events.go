@@ -0,0 +1,61 @@
+// Package webhooks delivers shop events to subscribed HTTP endpoints.
+//
+// Handlers record events with Enqueue inside the transaction that makes the
+// change (a transactional outbox), so an event exists exactly when its
+// change was committed. The Dispatcher then fans events out to matching
+// subscriptions and POSTs them, signed with the subscription's secret,
+// retrying failures with exponential backoff.
+package webhooks
+
+import (
+	"encoding/json"
+
+	"shop/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ProductCreated     = "product.created"
+	ProductUpdated     = "product.updated"
+	ProductDeleted     = "product.deleted"
+	CartCreated        = "cart.created"
+	CartProductAdded   = "cart.product_added"
+	CartProductRemoved = "cart.product_removed"
+	OrderPlaced        = "order.placed"
+)
+
+// EventTypes lists every event type a subscription can ask for.
+var EventTypes = []string{
+	ProductCreated,
+	ProductUpdated,
+	ProductDeleted,
+	CartCreated,
+	CartProductAdded,
+	CartProductRemoved,
+	OrderPlaced,
+}
+
+// ValidEventType reports whether eventType is known or the "*" wildcard.
+func ValidEventType(eventType string) bool {
+	if eventType == "*" {
+		return true
+	}
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Enqueue adds an event to the outbox of the store of tx's context. Call it
+// with the transaction that writes the change, so the event is only sent
+// if the change is committed.
+func Enqueue(tx *gorm.DB, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.WebhookEvent{Type: eventType, Payload: payload}).Error
+}
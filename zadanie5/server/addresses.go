@@ -0,0 +1,379 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Postal code formats of countries shipped to most; other countries accept
+// any short code of letters, digits, spaces and hyphens
+var postalCodePatterns = map[string]*regexp.Regexp{
+	"PL": regexp.MustCompile(`^\d{2}-\d{3}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"LT": regexp.MustCompile(`^(LT-)?\d{5}$`),
+	"CZ": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"SK": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"AT": regexp.MustCompile(`^\d{4}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+}
+
+var (
+	genericPostalCode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+	phonePattern      = regexp.MustCompile(`^\+?\d{6,15}$`)
+	phoneSeparators   = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+)
+
+// Where a payment is shipped or billed to; Country is an ISO 3166 code
+// such as PL
+type PostalAddress struct {
+	Name       string `json:"name"`
+	Company    string `json:"company"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+// Entry of a user's address book; a user with addresses has exactly one
+// default for shipping and one for billing
+type Address struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	UserID          uint   `json:"userId" gorm:"index"`
+	Label           string `json:"label"`
+	PostalAddress   `gorm:"embedded"`
+	DefaultShipping bool      `json:"defaultShipping"`
+	DefaultBilling  bool      `json:"defaultBilling"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type addressRequest struct {
+	Label string `json:"label"`
+	PostalAddress
+	DefaultShipping bool `json:"defaultShipping"`
+	DefaultBilling  bool `json:"defaultBilling"`
+}
+
+var errAddressNotFound = errors.New("address not found")
+
+func registerAddressRoutes(e *echo.Echo, db *gorm.DB) {
+	e.GET("/addresses", func(c echo.Context) error {
+		return handleGetAddresses(c, db)
+	})
+	e.POST("/addresses", func(c echo.Context) error {
+		return handleCreateAddress(c, db)
+	})
+	e.GET("/addresses/:id", func(c echo.Context) error {
+		return handleGetAddress(c, db)
+	})
+	e.PUT("/addresses/:id", func(c echo.Context) error {
+		return handleUpdateAddress(c, db)
+	})
+	e.DELETE("/addresses/:id", func(c echo.Context) error {
+		return handleDeleteAddress(c, db)
+	})
+}
+
+// Trim the fields and upper-case the country and postal code
+func normalizeAddress(address *PostalAddress) {
+	address.Name = strings.TrimSpace(address.Name)
+	address.Company = strings.TrimSpace(address.Company)
+	address.Street = strings.TrimSpace(address.Street)
+	address.City = strings.TrimSpace(address.City)
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(address.PostalCode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Phone = strings.TrimSpace(address.Phone)
+}
+
+// Validate a normalized address, returning the code of the first problem or ""
+func validateAddress(address PostalAddress) errorCode {
+	if address.Name == "" || address.Street == "" || address.City == "" || address.PostalCode == "" {
+		return codeAddressIncomplete
+	}
+	if !regionPattern.MatchString(address.Country) {
+		return codeInvalidCountry
+	}
+	if !validPostalCode(address.Country, address.PostalCode) {
+		return codeInvalidPostalCode
+	}
+	if address.Phone != "" && !phonePattern.MatchString(phoneSeparators.Replace(address.Phone)) {
+		return codeInvalidPhone
+	}
+	return ""
+}
+
+func validPostalCode(country, code string) bool {
+	if pattern, ok := postalCodePatterns[country]; ok {
+		return pattern.MatchString(code)
+	}
+	return genericPostalCode.MatchString(code)
+}
+
+// Signed-in user of the request; API keys have no address book
+func addressBookUser(c echo.Context, db *gorm.DB) (*User, error) {
+	p, err := authenticate(c, db)
+	if err != nil {
+		return nil, err
+	}
+	if p.user == nil {
+		return nil, errUnauthenticated
+	}
+	return p.user, nil
+}
+
+func findAddress(db *gorm.DB, userID uint, id interface{}) (Address, error) {
+	var address Address
+	err := db.Where("user_id = ?", userID).First(&address, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return address, errAddressNotFound
+	}
+	return address, err
+}
+
+// Store an address and take the defaults it sets from the user's other
+// addresses; the first address is the default for shipping and billing
+func saveAddress(tx *gorm.DB, address *Address) error {
+	var others int64
+	if err := tx.Model(&Address{}).Where("user_id = ? AND id <> ?", address.UserID, address.ID).Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		address.DefaultShipping, address.DefaultBilling = true, true
+	}
+
+	if err := tx.Save(address).Error; err != nil {
+		return err
+	}
+
+	for column, isDefault := range map[string]bool{"default_shipping": address.DefaultShipping, "default_billing": address.DefaultBilling} {
+		if !isDefault {
+			continue
+		}
+		err := tx.Model(&Address{}).
+			Where("user_id = ? AND id <> ?", address.UserID, address.ID).
+			UpdateColumn(column, false).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := promoteDefaults(tx, address.UserID); err != nil {
+		return err
+	}
+	return tx.First(address, address.ID).Error
+}
+
+// Make the user's oldest address the default for shipping or billing when
+// none is, e.g. after the default one was deleted
+func promoteDefaults(tx *gorm.DB, userID uint) error {
+	for _, column := range []string{"default_shipping", "default_billing"} {
+		var defaults int64
+		if err := tx.Model(&Address{}).Where("user_id = ? AND "+column+" = ?", userID, true).Count(&defaults).Error; err != nil {
+			return err
+		}
+		if defaults > 0 {
+			continue
+		}
+
+		var oldest Address
+		if err := tx.Where("user_id = ?", userID).Order("id").Limit(1).Find(&oldest).Error; err != nil {
+			return err
+		}
+		if oldest.ID == 0 {
+			return nil
+		}
+		if err := tx.Model(&oldest).UpdateColumn(column, true).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bind and validate an address request onto address
+func bindAddress(c echo.Context, address *Address) errorCode {
+	req := new(addressRequest)
+	if err := c.Bind(req); err != nil {
+		return codeInvalidRequest
+	}
+
+	normalizeAddress(&req.PostalAddress)
+	if code := validateAddress(req.PostalAddress); code != "" {
+		return code
+	}
+
+	address.Label = strings.TrimSpace(req.Label)
+	address.PostalAddress = req.PostalAddress
+	address.DefaultShipping = req.DefaultShipping
+	address.DefaultBilling = req.DefaultBilling
+	return ""
+}
+
+// Handle list addresses request, defaults first
+func handleGetAddresses(c echo.Context, db *gorm.DB) error {
+	user, err := addressBookUser(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	db = db.WithContext(c.Request().Context())
+
+	addresses := []Address{}
+	err = db.Where("user_id = ?", user.ID).
+		Order("default_shipping DESC, default_billing DESC, id").
+		Find(&addresses).Error
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, addresses)
+}
+
+func handleGetAddress(c echo.Context, db *gorm.DB) error {
+	user, err := addressBookUser(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	db = db.WithContext(c.Request().Context())
+
+	address, err := findAddress(db, user.ID, c.Param("id"))
+	if errors.Is(err, errAddressNotFound) {
+		return errorJSON(c, http.StatusNotFound, codeAddressNotFound)
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, address)
+}
+
+func handleCreateAddress(c echo.Context, db *gorm.DB) error {
+	user, err := addressBookUser(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	db = db.WithContext(c.Request().Context())
+
+	address := Address{UserID: user.ID}
+	if code := bindAddress(c, &address); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return saveAddress(tx, &address) }); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusCreated, address)
+}
+
+// Handle update address request; payments keep their copy of the old one
+func handleUpdateAddress(c echo.Context, db *gorm.DB) error {
+	user, err := addressBookUser(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	db = db.WithContext(c.Request().Context())
+
+	address, err := findAddress(db, user.ID, c.Param("id"))
+	if errors.Is(err, errAddressNotFound) {
+		return errorJSON(c, http.StatusNotFound, codeAddressNotFound)
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	if code := bindAddress(c, &address); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return saveAddress(tx, &address) }); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, address)
+}
+
+func handleDeleteAddress(c echo.Context, db *gorm.DB) error {
+	user, err := addressBookUser(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	db = db.WithContext(c.Request().Context())
+
+	address, err := findAddress(db, user.ID, c.Param("id"))
+	if errors.Is(err, errAddressNotFound) {
+		return errorJSON(c, http.StatusNotFound, codeAddressNotFound)
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		return promoteDefaults(tx, user.ID)
+	})
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Copy the payment's addresses onto it: entries of the signed-in user's
+// address book named by id, else addresses given in the request, else the
+// user's defaults. Billing falls back to the shipping address.
+func applyPaymentAddresses(c echo.Context, db *gorm.DB, payment *Payment) (int, errorCode) {
+	var userID uint
+	if user, err := addressBookUser(c, db); err == nil {
+		userID = user.ID
+	} else if !errors.Is(err, errUnauthenticated) {
+		return http.StatusInternalServerError, codeInternal
+	}
+
+	pick := func(id *uint, given *PostalAddress, defaultColumn string) (int, errorCode) {
+		if id != nil {
+			address, err := findAddress(db, userID, *id)
+			if errors.Is(err, errAddressNotFound) {
+				return http.StatusNotFound, codeAddressNotFound
+			}
+			if err != nil {
+				return http.StatusInternalServerError, codeInternal
+			}
+			*given = address.PostalAddress
+			return 0, ""
+		}
+		if *given != (PostalAddress{}) {
+			normalizeAddress(given)
+			if code := validateAddress(*given); code != "" {
+				return http.StatusBadRequest, code
+			}
+			return 0, ""
+		}
+		if userID == 0 {
+			return 0, ""
+		}
+		var address Address
+		if err := db.Where("user_id = ? AND "+defaultColumn+" = ?", userID, true).Limit(1).Find(&address).Error; err != nil {
+			return http.StatusInternalServerError, codeInternal
+		}
+		*given = address.PostalAddress
+		return 0, ""
+	}
+
+	if status, code := pick(payment.ShippingAddressID, &payment.ShippingAddress, "default_shipping"); code != "" {
+		return status, code
+	}
+	if status, code := pick(payment.BillingAddressID, &payment.BillingAddress, "default_billing"); code != "" {
+		return status, code
+	}
+	if payment.BillingAddress == (PostalAddress{}) {
+		payment.BillingAddress = payment.ShippingAddress
+	}
+	return 0, ""
+}
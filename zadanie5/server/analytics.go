@@ -0,0 +1,387 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout          = "2006-01-02"
+	defaultReportPeriod = 30 * 24 * time.Hour
+	defaultTopProducts  = 10
+	maxTopProducts      = 100
+)
+
+// Product added to the cart; kept after checkout empties the cart so
+// additions can be reported on
+type CartAddition struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"productId" gorm:"index"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// Cart line paid for by a payment, priced when it was paid
+type PaymentItem struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	PaymentID uint    `json:"paymentId" gorm:"index"`
+	ProductID uint    `json:"productId" gorm:"index"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+type revenueRow struct {
+	Period   string  `json:"period"`
+	Payments int64   `json:"payments"`
+	Revenue  float64 `json:"revenue"`
+}
+
+type topProductRow struct {
+	ProductID uint   `json:"productId"`
+	Name      string `json:"name"`
+	Additions int64  `json:"additions"`
+	Quantity  int64  `json:"quantity"`
+}
+
+// Checked-out carts are paid, expired ones abandoned
+type cartReport struct {
+	Carts            int64   `json:"carts"`
+	PaidCarts        int64   `json:"paidCarts"`
+	AbandonedCarts   int64   `json:"abandonedCarts"`
+	ConversionRate   float64 `json:"conversionRate"`
+	AverageCartValue float64 `json:"averageCartValue"`
+}
+
+type categoryRow struct {
+	Category     string  `json:"category"`
+	Additions    int64   `json:"additions"`
+	QuantitySold int64   `json:"quantitySold"`
+	Revenue      float64 `json:"revenue"`
+}
+
+// Half-open range [from, to) taken from ?from and ?to (YYYY-MM-DD, to
+// inclusive); defaults to the last 30 days
+type reportRange struct {
+	from, to time.Time
+}
+
+func registerAnalyticsRoutes(e *echo.Echo, db *gorm.DB) {
+	readAnalytics := requirePermission(db, permAnalyticsRead)
+	e.GET("/analytics/revenue", func(c echo.Context) error {
+		return handleRevenueReport(c, db)
+	}, readAnalytics)
+	e.GET("/analytics/top-products", func(c echo.Context) error {
+		return handleTopProductsReport(c, db)
+	}, readAnalytics)
+	e.GET("/analytics/carts", func(c echo.Context) error {
+		return handleCartReport(c, db)
+	}, readAnalytics)
+	e.GET("/analytics/categories", func(c echo.Context) error {
+		return handleCategoryReport(c, db)
+	}, readAnalytics)
+}
+
+// Parse ?from and ?to in UTC
+func parseReportRange(c echo.Context, now time.Time) (reportRange, errorCode) {
+	r := reportRange{
+		from: now.UTC().Truncate(24 * time.Hour).Add(-defaultReportPeriod + 24*time.Hour),
+		to:   now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour),
+	}
+
+	if value := c.QueryParam("from"); value != "" {
+		from, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return r, codeInvalidDateRange
+		}
+		r.from = from
+	}
+	if value := c.QueryParam("to"); value != "" {
+		to, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return r, codeInvalidDateRange
+		}
+		r.to = to.Add(24 * time.Hour)
+	}
+	if !r.from.Before(r.to) {
+		return r, codeInvalidDateRange
+	}
+	return r, ""
+}
+
+// Label of the day, ISO week or month holding t
+func reportPeriod(t time.Time, interval string) string {
+	t = t.UTC()
+	switch interval {
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return t.Format("2006-01")
+	default:
+		return t.Format(dateLayout)
+	}
+}
+
+// Whether the client asked for CSV with ?format=csv or Accept: text/csv
+func wantsCSV(c echo.Context) bool {
+	if format := c.QueryParam("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "text/csv")
+}
+
+// Respond with value as JSON, or with header and rows as a CSV attachment
+func reportResponse(c echo.Context, name string, value interface{}, header []string, rows [][]string) error {
+	if !wantsCSV(c) {
+		return c.JSON(http.StatusOK, value)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`.csv"`)
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func formatMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Payments and revenue per day, week or month, oldest first
+func revenueReport(db *gorm.DB, r reportRange, interval string) ([]revenueRow, error) {
+	var payments []Payment
+	err := db.Select("amount", "created_at").
+		Where("status = ? AND created_at >= ? AND created_at < ?", "completed", r.from, r.to).
+		Order("created_at").
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []revenueRow{}
+	for _, payment := range payments {
+		period := reportPeriod(payment.CreatedAt, interval)
+		if len(rows) == 0 || rows[len(rows)-1].Period != period {
+			rows = append(rows, revenueRow{Period: period})
+		}
+		rows[len(rows)-1].Payments++
+		rows[len(rows)-1].Revenue += payment.Amount
+	}
+	return rows, nil
+}
+
+// Products added to the cart most often
+func topProductsReport(db *gorm.DB, r reportRange, limit int) ([]topProductRow, error) {
+	rows := []topProductRow{}
+	err := db.Model(&CartAddition{}).
+		Select("cart_additions.product_id, products.name, COUNT(*) AS additions, SUM(cart_additions.quantity) AS quantity").
+		Joins("JOIN products ON products.id = cart_additions.product_id").
+		Where("cart_additions.created_at >= ? AND cart_additions.created_at < ?", r.from, r.to).
+		Group("cart_additions.product_id, products.name").
+		Order("additions DESC, quantity DESC, cart_additions.product_id").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func cartSummary(db *gorm.DB, r reportRange) (cartReport, error) {
+	var report cartReport
+	var paid struct {
+		Count int64
+		Total float64
+	}
+	err := db.Model(&Payment{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS total").
+		Where("status = ? AND created_at >= ? AND created_at < ?", "completed", r.from, r.to).
+		Scan(&paid).Error
+	if err != nil {
+		return report, err
+	}
+
+	err = db.Model(&CartAbandonment{}).
+		Where("created_at >= ? AND created_at < ?", r.from, r.to).
+		Count(&report.AbandonedCarts).Error
+	if err != nil {
+		return report, err
+	}
+
+	report.PaidCarts = paid.Count
+	report.Carts = report.PaidCarts + report.AbandonedCarts
+	if report.Carts > 0 {
+		report.ConversionRate = float64(report.PaidCarts) / float64(report.Carts)
+	}
+	if report.PaidCarts > 0 {
+		report.AverageCartValue = paid.Total / float64(report.PaidCarts)
+	}
+	return report, nil
+}
+
+// Additions, units sold and revenue per product category
+func categoryReport(db *gorm.DB, r reportRange) ([]categoryRow, error) {
+	var additions []categoryRow
+	err := db.Model(&CartAddition{}).
+		Select("products.category, COUNT(*) AS additions").
+		Joins("JOIN products ON products.id = cart_additions.product_id").
+		Where("cart_additions.created_at >= ? AND cart_additions.created_at < ?", r.from, r.to).
+		Group("products.category").
+		Scan(&additions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var sales []categoryRow
+	err = db.Model(&PaymentItem{}).
+		Select("products.category, SUM(payment_items.quantity) AS quantity_sold, SUM(payment_items.quantity * payment_items.price) AS revenue").
+		Joins("JOIN payments ON payments.id = payment_items.payment_id").
+		Joins("JOIN products ON products.id = payment_items.product_id").
+		Where("payments.status = ? AND payments.created_at >= ? AND payments.created_at < ?", "completed", r.from, r.to).
+		Group("products.category").
+		Scan(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []categoryRow{}
+	index := map[string]int{}
+	for _, group := range [][]categoryRow{additions, sales} {
+		for _, row := range group {
+			i, ok := index[row.Category]
+			if !ok {
+				i = len(rows)
+				index[row.Category] = i
+				rows = append(rows, categoryRow{Category: row.Category})
+			}
+			rows[i].Additions += row.Additions
+			rows[i].QuantitySold += row.QuantitySold
+			rows[i].Revenue += row.Revenue
+		}
+	}
+	return rows, nil
+}
+
+// Handle revenue report request, grouped by ?interval=day|week|month
+func handleRevenueReport(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	r, code := parseReportRange(c, time.Now())
+	if code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+	interval := c.QueryParam("interval")
+	switch interval {
+	case "":
+		interval = "day"
+	case "day", "week", "month":
+	default:
+		return errorJSON(c, http.StatusBadRequest, codeInvalidInterval)
+	}
+
+	report, err := revenueReport(db, r, interval)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	rows := make([][]string, len(report))
+	for i, row := range report {
+		rows[i] = []string{row.Period, strconv.FormatInt(row.Payments, 10), formatMoney(row.Revenue)}
+	}
+	return reportResponse(c, "revenue", report, []string{"period", "payments", "revenue"}, rows)
+}
+
+// Handle top products request, ?limit products by cart additions
+func handleTopProductsReport(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	r, code := parseReportRange(c, time.Now())
+	if code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultTopProducts
+	}
+	if limit > maxTopProducts {
+		limit = maxTopProducts
+	}
+
+	report, err := topProductsReport(db, r, limit)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	rows := make([][]string, len(report))
+	for i, row := range report {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(row.ProductID), 10),
+			row.Name,
+			strconv.FormatInt(row.Additions, 10),
+			strconv.FormatInt(row.Quantity, 10),
+		}
+	}
+	return reportResponse(c, "top-products", report, []string{"productId", "name", "additions", "quantity"}, rows)
+}
+
+// Handle cart conversion and average cart value request
+func handleCartReport(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	r, code := parseReportRange(c, time.Now())
+	if code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	report, err := cartSummary(db, r)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	row := []string{
+		strconv.FormatInt(report.Carts, 10),
+		strconv.FormatInt(report.PaidCarts, 10),
+		strconv.FormatInt(report.AbandonedCarts, 10),
+		strconv.FormatFloat(report.ConversionRate, 'f', 4, 64),
+		formatMoney(report.AverageCartValue),
+	}
+	return reportResponse(c, "carts", report, []string{"carts", "paidCarts", "abandonedCarts", "conversionRate", "averageCartValue"}, [][]string{row})
+}
+
+// Handle category performance request
+func handleCategoryReport(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	r, code := parseReportRange(c, time.Now())
+	if code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	report, err := categoryReport(db, r)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	rows := make([][]string, len(report))
+	for i, row := range report {
+		rows[i] = []string{
+			row.Category,
+			strconv.FormatInt(row.Additions, 10),
+			strconv.FormatInt(row.QuantitySold, 10),
+			formatMoney(row.Revenue),
+		}
+	}
+	return reportResponse(c, "categories", report, []string{"category", "additions", "quantitySold", "revenue"}, rows)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyPrefix = "sk_"
+	// How often lastUsedAt is written for a busy key
+	lastUsedResolution = time.Minute
+)
+
+// Key for an integration; it carries the permissions it was scoped to instead
+// of a role. Only the SHA-256 is stored and Key is set only in the response
+// that creates or rotates it
+type APIKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	KeyHash     string     `json:"-" gorm:"uniqueIndex"`
+	Scopes      []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedByID uint       `json:"createdById"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	RevokedAt   *time.Time `json:"revokedAt" gorm:"index"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	Key         string     `json:"key,omitempty" gorm:"-"`
+}
+
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Look up an active key and record that it was used
+func authenticateAPIKey(db *gorm.DB, key string) (*APIKey, error) {
+	now := time.Now()
+	var apiKey APIKey
+	err := db.Where("key_hash = ? AND revoked_at IS NULL", hashToken(key)).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		if err := db.Model(&apiKey).UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &apiKey, nil
+}
+
+// Fresh key with its stored hash and the prefix shown in listings
+func newAPIKey() (key, hash, prefix string, err error) {
+	key, err = newToken(apiKeyPrefix)
+	if err != nil {
+		return "", "", "", err
+	}
+	return key, hashToken(key), key[:len(apiKeyPrefix)+8], nil
+}
+
+// Check name, scopes and expiry of a create or update request
+func validateAPIKey(req *apiKeyRequest) errorCode {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return codeNameRequired
+	}
+	if len(req.Scopes) == 0 {
+		return codeInvalidScopes
+	}
+	for _, scope := range req.Scopes {
+		known := false
+		for _, p := range permissions {
+			if string(p) == scope {
+				known = true
+			}
+		}
+		if !known {
+			return codeInvalidScopes
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return codeInvalidExpiry
+	}
+	return ""
+}
+
+func registerAPIKeyRoutes(e *echo.Echo, db *gorm.DB) {
+	manage := requirePermission(db, permAPIKeysManage)
+	e.POST("/admin/api-keys", func(c echo.Context) error {
+		return handleCreateAPIKey(c, db)
+	}, manage)
+	e.GET("/admin/api-keys", func(c echo.Context) error {
+		return handleGetAPIKeys(c, db)
+	}, manage)
+	e.GET("/admin/api-keys/scopes", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, permissions)
+	}, manage)
+	e.PUT("/admin/api-keys/:id", func(c echo.Context) error {
+		return handleUpdateAPIKey(c, db)
+	}, manage)
+	e.DELETE("/admin/api-keys/:id", func(c echo.Context) error {
+		return handleRevokeAPIKey(c, db)
+	}, manage)
+	e.POST("/admin/api-keys/:id/rotate", func(c echo.Context) error {
+		return handleRotateAPIKey(c, db)
+	}, manage)
+}
+
+// Handle create API key request; the response is the only one with the key
+func handleCreateAPIKey(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(apiKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if code := validateAPIKey(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	key, hash, prefix, err := newAPIKey()
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	apiKey := APIKey{Name: req.Name, Prefix: prefix, KeyHash: hash, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	if p, ok := c.Get(principalKey).(*principal); ok && p.user != nil {
+		apiKey.CreatedByID = p.user.ID
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	apiKey.Key = key
+	return c.JSON(http.StatusCreated, apiKey)
+}
+
+// Handle list API keys request; ?active=true hides revoked and expired keys
+func handleGetAPIKeys(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	keys := []APIKey{}
+	query := db.Order("id")
+	if c.QueryParam("active") == "true" {
+		query = query.Where("revoked_at IS NULL").Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
+	if err := query.Find(&keys).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+// Handle update API key request; the key value stays the same
+func handleUpdateAPIKey(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var apiKey APIKey
+	if err := db.First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeAPIKeyNotFound)
+	}
+	if apiKey.RevokedAt != nil {
+		return errorJSON(c, http.StatusConflict, codeAPIKeyRevoked)
+	}
+
+	req := new(apiKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if code := validateAPIKey(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	apiKey.Name = req.Name
+	apiKey.Scopes = req.Scopes
+	apiKey.ExpiresAt = req.ExpiresAt
+	if err := db.Save(&apiKey).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, apiKey)
+}
+
+// Handle rotate API key request; the old value stops working immediately
+func handleRotateAPIKey(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var apiKey APIKey
+	if err := db.First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeAPIKeyNotFound)
+	}
+	if apiKey.RevokedAt != nil {
+		return errorJSON(c, http.StatusConflict, codeAPIKeyRevoked)
+	}
+
+	key, hash, prefix, err := newAPIKey()
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	apiKey.KeyHash = hash
+	apiKey.Prefix = prefix
+	apiKey.LastUsedAt = nil
+	if err := db.Save(&apiKey).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	apiKey.Key = key
+	return c.JSON(http.StatusOK, apiKey)
+}
+
+// Handle revoke API key request; the row stays for the audit trail
+func handleRevokeAPIKey(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var apiKey APIKey
+	if err := db.First(&apiKey, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeAPIKeyNotFound)
+	}
+
+	if apiKey.RevokedAt == nil {
+		now := time.Now()
+		apiKey.RevokedAt = &now
+		if err := db.Model(&apiKey).Update("revoked_at", now).Error; err != nil {
+			return errorJSON(c, http.StatusInternalServerError, codeInternal)
+		}
+	}
+	return c.JSON(http.StatusOK, apiKey)
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	roleCustomer      = "customer"
+	roleStaff         = "staff"
+	roleAdmin         = "admin"
+	minPasswordLength = 8
+	principalKey      = "principal"
+)
+
+// Action a role may be allowed to perform
+type permission string
+
+const (
+	permPaymentsRead   permission = "payments:read"
+	permWebhooksManage permission = "webhooks:manage"
+	permUsersManage    permission = "users:manage"
+	permAPIKeysManage  permission = "api_keys:manage"
+	permAnalyticsRead  permission = "analytics:read"
+	permTaxManage      permission = "tax:manage"
+)
+
+// Every permission, in the order they are documented
+var permissions = []permission{permPaymentsRead, permWebhooksManage, permUsersManage, permAPIKeysManage, permAnalyticsRead, permTaxManage}
+
+// Permissions granted to each role; customers only use the public routes
+var rolePermissions = map[string][]permission{
+	roleCustomer: {},
+	roleStaff:    {permPaymentsRead, permAnalyticsRead},
+	roleAdmin:    {permPaymentsRead, permWebhooksManage, permUsersManage, permAPIKeysManage, permAnalyticsRead, permTaxManage},
+}
+
+// Account signing in with an email and password
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"uniqueIndex"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role" gorm:"default:customer"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Bearer token issued at login; only its SHA-256 is stored
+type Session struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"index"`
+	User      User      `json:"-"`
+	TokenHash string    `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type registerRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      User      `json:"user"`
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+var errUnauthenticated = errors.New("authentication required")
+
+// Authenticated caller: a signed-in user or an integration using an API key
+type principal struct {
+	user   *User
+	apiKey *APIKey
+}
+
+// Report whether the caller may perform perm; API keys are limited to their scopes
+func (p *principal) can(perm permission) bool {
+	if p.apiKey != nil {
+		for _, scope := range p.apiKey.Scopes {
+			if scope == string(perm) {
+				return true
+			}
+		}
+		return false
+	}
+	return roleCan(p.user.Role, perm)
+}
+
+// Report whether role grants perm
+func roleCan(role string, perm permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Random token starting with prefix
+func newToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// Hash a token the way it is stored in sessions and API keys
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Token from an "Authorization: Bearer ..." header, or "" when missing
+func bearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Resolve the caller from the X-API-Key header or the bearer token; the
+// result is cached on the context so handlers behind requirePermission don't
+// look it up again
+func authenticate(c echo.Context, db *gorm.DB) (*principal, error) {
+	if p, ok := c.Get(principalKey).(*principal); ok {
+		return p, nil
+	}
+
+	if key := c.Request().Header.Get(apiKeyHeader); key != "" {
+		apiKey, err := authenticateAPIKey(db.WithContext(c.Request().Context()), key)
+		if err != nil {
+			return nil, err
+		}
+		p := &principal{apiKey: apiKey}
+		c.Set(principalKey, p)
+		return p, nil
+	}
+
+	token := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+
+	var session Session
+	err := db.WithContext(c.Request().Context()).Joins("User").
+		Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now()).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.User.ID == 0) {
+		return nil, errUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &principal{user: &session.User}
+	c.Set(principalKey, p)
+	return p, nil
+}
+
+// Middleware letting the request through only when the caller may perform perm
+func requirePermission(db *gorm.DB, perm permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p, err := authenticate(c, db)
+			if err != nil {
+				return denyUnauthenticated(c, err)
+			}
+			if !p.can(perm) {
+				return errorJSON(c, http.StatusForbidden, codeForbidden)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Answer 401 for a missing or invalid token, 500 for a lookup failure
+func denyUnauthenticated(c echo.Context, err error) error {
+	if errors.Is(err, errUnauthenticated) {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		return errorJSON(c, http.StatusUnauthorized, codeUnauthenticated)
+	}
+	return errorJSON(c, http.StatusInternalServerError, codeInternal)
+}
+
+// Create the admin account from ADMIN_EMAIL and ADMIN_PASSWORD unless it exists
+func ensureAdmin(db *gorm.DB, email, password string) (bool, error) {
+	email = normalizeEmail(email)
+
+	var count int64
+	if err := db.Model(&User{}).Where("email = ?", email).Count(&count).Error; err != nil || count > 0 {
+		return false, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	user := User{Email: email, Name: "Administrator", Role: roleAdmin, PasswordHash: string(hash)}
+	return true, db.Create(&user).Error
+}
+
+func registerAuthRoutes(e *echo.Echo, db *gorm.DB) {
+	e.POST("/auth/register", func(c echo.Context) error {
+		return handleRegister(c, db)
+	})
+	e.POST("/auth/login", func(c echo.Context) error {
+		return handleLogin(c, db)
+	})
+	e.POST("/auth/logout", func(c echo.Context) error {
+		return handleLogout(c, db)
+	})
+	e.GET("/auth/me", func(c echo.Context) error {
+		return handleMe(c, db)
+	})
+
+	manageUsers := requirePermission(db, permUsersManage)
+	e.GET("/admin/users", func(c echo.Context) error {
+		return handleGetUsers(c, db)
+	}, manageUsers)
+	e.PUT("/admin/users/:id/role", func(c echo.Context) error {
+		return handleUpdateUserRole(c, db)
+	}, manageUsers)
+}
+
+// Handle register request; new accounts are always customers
+func handleRegister(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(registerRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+
+	req.Email = normalizeEmail(req.Email)
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidEmail)
+	}
+	if len(req.Password) < minPasswordLength {
+		return errorJSON(c, http.StatusBadRequest, codePasswordTooShort)
+	}
+
+	var count int64
+	if err := db.Model(&User{}).Where("email = ?", req.Email).Count(&count).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	if count > 0 {
+		return errorJSON(c, http.StatusConflict, codeEmailTaken)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	user := User{Email: req.Email, Name: req.Name, Role: roleCustomer, PasswordHash: string(hash)}
+	if err := db.Create(&user).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusCreated, user)
+}
+
+// Handle login request; the token is valid for SESSION_TTL
+func handleLogin(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(loginRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+
+	var user User
+	err := db.Where("email = ?", normalizeEmail(req.Email)).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return errorJSON(c, http.StatusUnauthorized, codeInvalidCredentials)
+	}
+
+	token, err := newToken("sess_")
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+
+	session := Session{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(durationFromEnv("SESSION_TTL", 24*time.Hour)),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, loginResponse{Token: token, ExpiresAt: session.ExpiresAt, User: user})
+}
+
+// Handle logout request by revoking the bearer token
+func handleLogout(c echo.Context, db *gorm.DB) error {
+	if _, err := authenticate(c, db); err != nil {
+		return denyUnauthenticated(c, err)
+	}
+
+	db = db.WithContext(c.Request().Context())
+	token := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+	if err := db.Where("token_hash = ?", hashToken(token)).Delete(&Session{}).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Handle request for the authenticated account; API keys have none
+func handleMe(c echo.Context, db *gorm.DB) error {
+	p, err := authenticate(c, db)
+	if err != nil {
+		return denyUnauthenticated(c, err)
+	}
+	if p.user == nil {
+		return errorJSON(c, http.StatusNotFound, codeUserNotFound)
+	}
+	return c.JSON(http.StatusOK, p.user)
+}
+
+// Handle list users request, optionally filtered by ?role
+func handleGetUsers(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	users := []User{}
+	query := db.Order("id")
+	if role := c.QueryParam("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+// Handle role assignment; existing sessions get the new role on their next request
+func handleUpdateUserRole(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var user User
+	if err := db.First(&user, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeUserNotFound)
+	}
+
+	req := new(roleRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if _, ok := rolePermissions[req.Role]; !ok {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRole)
+	}
+
+	if err := db.Model(&user).Update("role", req.Role).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, user)
+}
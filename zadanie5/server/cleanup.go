@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CartAbandonment records a cart that was emptied by the cleanup worker after
+// a period of inactivity.
+type CartAbandonment struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ItemCount      int       `json:"itemCount"`
+	Quantity       int       `json:"quantity"`
+	Value          float64   `json:"value"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Read a duration such as "72h" from the environment
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		slog.Warn("invalid duration in environment", "name", name, "value", value, "fallback", fallback)
+		return fallback
+	}
+
+	return duration
+}
+
+// Handle one-off commands, e.g. `./main expire-carts`
+func runCommand(db *gorm.DB, name string) {
+	switch name {
+	case "expire-carts":
+		expired, err := expireCart(db, durationFromEnv("CART_INACTIVITY_TTL", 72*time.Hour))
+		if err != nil {
+			slog.Error("expiring cart", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("cart expiry finished", "expired", expired)
+	default:
+		slog.Error("unknown command", "command", name)
+		os.Exit(1)
+	}
+}
+
+// Periodically expire the cart until the process exits
+func startCartCleanup(db *gorm.DB, interval, inactivity time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := expireCart(db, inactivity)
+		if err != nil {
+			slog.Error("expiring cart", "error", err)
+		} else if expired {
+			slog.Info("expired abandoned cart")
+		}
+	}
+}
+
+// Set the timestamps of cart items created before they were tracked to now,
+// so the cleanup worker counts their inactivity from the upgrade
+func backfillCartTimestamps(db *gorm.DB) error {
+	now := time.Now()
+	return db.Model(&CartItem{}).
+		Where("updated_at IS NULL OR updated_at < ?", time.Unix(0, 0)).
+		UpdateColumns(map[string]interface{}{"created_at": now, "updated_at": now}).Error
+}
+
+// Empty the cart when none of its items changed for longer than inactivity,
+// recording the abandonment. Items without a timestamp never expire the
+// cart. Reports whether the cart was expired.
+func expireCart(db *gorm.DB, inactivity time.Duration) (bool, error) {
+	var cartItems []CartItem
+	if err := db.Preload("Product").Find(&cartItems).Error; err != nil {
+		return false, err
+	}
+	if len(cartItems) == 0 {
+		return false, nil
+	}
+
+	abandonment := CartAbandonment{ItemCount: len(cartItems)}
+	for _, item := range cartItems {
+		if item.UpdatedAt.IsZero() {
+			return false, nil
+		}
+		abandonment.Quantity += item.Quantity
+		abandonment.Value += item.Product.Price * float64(item.Quantity)
+		if item.UpdatedAt.After(abandonment.LastActivityAt) {
+			abandonment.LastActivityAt = item.UpdatedAt
+		}
+	}
+
+	if time.Since(abandonment.LastActivityAt) < inactivity {
+		return false, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&abandonment).Error; err != nil {
+			return err
+		}
+		return tx.Where("updated_at <= ?", abandonment.LastActivityAt).Delete(&CartItem{}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
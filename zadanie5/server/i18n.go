@@ -0,0 +1,228 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+// Stable error code returned next to the message; clients should match on
+// the code, as the message depends on Accept-Language
+type errorCode string
+
+const (
+	codeInvalidRequest        errorCode = "invalid_request"
+	codeInternal              errorCode = "internal_error"
+	codeNotFound              errorCode = "not_found"
+	codeMethodNotAllowed      errorCode = "method_not_allowed"
+	codeTooManyRequests       errorCode = "too_many_requests"
+	codeProductsUnavailable   errorCode = "products_unavailable"
+	codeCartUnavailable       errorCode = "cart_unavailable"
+	codeInvalidCartItem       errorCode = "invalid_cart_item"
+	codeProductNotFound       errorCode = "product_not_found"
+	codeCartAddFailed         errorCode = "cart_add_failed"
+	codePaymentsUnavailable   errorCode = "payments_unavailable"
+	codeInvalidPayment        errorCode = "invalid_payment"
+	codePaymentFailed         errorCode = "payment_failed"
+	codeCardNumberRequired    errorCode = "card_number_required"
+	codeInvalidCardNumber     errorCode = "invalid_card_number"
+	codeCardHolderRequired    errorCode = "card_holder_required"
+	codeExpiryDateRequired    errorCode = "expiry_date_required"
+	codeInvalidExpiryDate     errorCode = "invalid_expiry_date"
+	codeCVVRequired           errorCode = "cvv_required"
+	codeInvalidCVV            errorCode = "invalid_cvv"
+	codeInvalidAmount         errorCode = "invalid_amount"
+	codePaymentAmountMismatch errorCode = "payment_amount_mismatch"
+	codeWebhookNotFound       errorCode = "webhook_not_found"
+	codeInvalidWebhookURL     errorCode = "invalid_webhook_url"
+	codeInvalidWebhookEvents  errorCode = "invalid_webhook_events"
+	codeDeliveryNotFound      errorCode = "webhook_delivery_not_found"
+	codeInvalidDeliveryStatus errorCode = "invalid_delivery_status"
+	codeUnauthenticated       errorCode = "unauthenticated"
+	codeForbidden             errorCode = "forbidden"
+	codeInvalidCredentials    errorCode = "invalid_credentials"
+	codeInvalidEmail          errorCode = "invalid_email"
+	codeEmailTaken            errorCode = "email_taken"
+	codePasswordTooShort      errorCode = "password_too_short"
+	codeInvalidRole           errorCode = "invalid_role"
+	codeUserNotFound          errorCode = "user_not_found"
+	codeNameRequired          errorCode = "name_required"
+	codeAPIKeyNotFound        errorCode = "api_key_not_found"
+	codeAPIKeyRevoked         errorCode = "api_key_revoked"
+	codeInvalidScopes         errorCode = "invalid_scopes"
+	codeInvalidExpiry         errorCode = "invalid_expiry"
+	codeInvalidDateRange      errorCode = "invalid_date_range"
+	codeInvalidInterval       errorCode = "invalid_interval"
+	codeInvalidTaxClass       errorCode = "invalid_tax_class"
+	codeInvalidRegion         errorCode = "invalid_region"
+	codeAddressNotFound       errorCode = "address_not_found"
+	codeAddressIncomplete     errorCode = "address_incomplete"
+	codeInvalidCountry        errorCode = "invalid_country"
+	codeInvalidPostalCode     errorCode = "invalid_postal_code"
+	codeInvalidPhone          errorCode = "invalid_phone"
+)
+
+// Language used when Accept-Language names nothing we have a catalogue for
+const defaultLanguage = "pl"
+
+var errorMessages = map[string]map[errorCode]string{
+	"pl": {
+		codeInvalidRequest:        "Nieprawidłowe żądanie",
+		codeInternal:              "Wewnętrzny błąd serwera",
+		codeNotFound:              "Nie znaleziono",
+		codeMethodNotAllowed:      "Metoda niedozwolona",
+		codeTooManyRequests:       "Zbyt wiele żądań, spróbuj ponownie później",
+		codeProductsUnavailable:   "Nie udało się pobrać produktów",
+		codeCartUnavailable:       "Nie udało się pobrać koszyka",
+		codeInvalidCartItem:       "Nieprawidłowe dane",
+		codeProductNotFound:       "Produkt nie istnieje",
+		codeCartAddFailed:         "Nie udało się dodać do koszyka",
+		codePaymentsUnavailable:   "Nie udało się pobrać historii płatności",
+		codeInvalidPayment:        "Nieprawidłowe dane płatności",
+		codePaymentFailed:         "Błąd przetwarzania płatności",
+		codeCardNumberRequired:    "Numer karty jest wymagany",
+		codeInvalidCardNumber:     "Nieprawidłowy format numeru karty",
+		codeCardHolderRequired:    "Imię i nazwisko jest wymagane",
+		codeExpiryDateRequired:    "Data ważności jest wymagana",
+		codeInvalidExpiryDate:     "Nieprawidłowy format daty",
+		codeCVVRequired:           "Kod CVV jest wymagany",
+		codeInvalidCVV:            "Nieprawidłowy format CVV",
+		codeInvalidAmount:         "Kwota płatności jest nieprawidłowa",
+		codePaymentAmountMismatch: "Kwota płatności musi być równa wartości koszyka brutto",
+		codeWebhookNotFound:       "Webhook nie istnieje",
+		codeInvalidWebhookURL:     "Adres webhooka musi być bezwzględnym adresem http lub https",
+		codeInvalidWebhookEvents:  "Lista zdarzeń musi zawierać co najmniej jeden znany typ lub *",
+		codeDeliveryNotFound:      "Dostawa webhooka nie istnieje",
+		codeInvalidDeliveryStatus: "Status musi mieć wartość pending, succeeded lub failed",
+		codeUnauthenticated:       "Wymagane uwierzytelnienie",
+		codeForbidden:             "Brak uprawnień do wykonania tej operacji",
+		codeInvalidCredentials:    "Nieprawidłowy e-mail lub hasło",
+		codeInvalidEmail:          "Wymagany jest poprawny adres e-mail",
+		codeEmailTaken:            "Konto z tym adresem e-mail już istnieje",
+		codePasswordTooShort:      "Hasło musi mieć co najmniej 8 znaków",
+		codeInvalidRole:           "Rola musi mieć wartość customer, staff lub admin",
+		codeUserNotFound:          "Użytkownik nie istnieje",
+		codeNameRequired:          "Nazwa jest wymagana",
+		codeAPIKeyNotFound:        "Klucz API nie istnieje",
+		codeAPIKeyRevoked:         "Klucz API został unieważniony",
+		codeInvalidScopes:         "Lista uprawnień musi zawierać co najmniej jedno znane uprawnienie",
+		codeInvalidExpiry:         "Data wygaśnięcia musi być w przyszłości",
+		codeInvalidDateRange:      "Daty from i to muszą mieć format RRRR-MM-DD, a from nie może być późniejsza niż to",
+		codeInvalidInterval:       "Interwał musi mieć wartość day, week lub month",
+		codeInvalidTaxClass:       "Klasa podatkowa musi mieć wartość standard, reduced, super_reduced lub zero",
+		codeInvalidRegion:         "Region musi być dwuliterowym kodem kraju, np. PL",
+		codeAddressNotFound:       "Adres nie istnieje",
+		codeAddressIncomplete:     "Imię i nazwisko, ulica, miasto i kod pocztowy są wymagane",
+		codeInvalidCountry:        "Kraj musi być dwuliterowym kodem kraju",
+		codeInvalidPostalCode:     "Kod pocztowy nie pasuje do formatu kraju",
+		codeInvalidPhone:          "Telefon musi mieć od 6 do 15 cyfr, opcjonalnie poprzedzonych znakiem +",
+	},
+	"en": {
+		codeInvalidRequest:        "Invalid request",
+		codeInternal:              "Internal server error",
+		codeNotFound:              "Not found",
+		codeMethodNotAllowed:      "Method not allowed",
+		codeTooManyRequests:       "Too many requests, try again later",
+		codeProductsUnavailable:   "Could not load products",
+		codeCartUnavailable:       "Could not load the cart",
+		codeInvalidCartItem:       "Invalid data",
+		codeProductNotFound:       "Product not found",
+		codeCartAddFailed:         "Could not add to the cart",
+		codePaymentsUnavailable:   "Could not load the payment history",
+		codeInvalidPayment:        "Invalid payment data",
+		codePaymentFailed:         "Payment processing failed",
+		codeCardNumberRequired:    "Card number is required",
+		codeInvalidCardNumber:     "Invalid card number format",
+		codeCardHolderRequired:    "Card holder name is required",
+		codeExpiryDateRequired:    "Expiry date is required",
+		codeInvalidExpiryDate:     "Invalid expiry date format",
+		codeCVVRequired:           "CVV is required",
+		codeInvalidCVV:            "Invalid CVV format",
+		codeInvalidAmount:         "Invalid payment amount",
+		codePaymentAmountMismatch: "Payment amount must equal the cart total including tax",
+		codeWebhookNotFound:       "Webhook not found",
+		codeInvalidWebhookURL:     "Webhook URL must be an absolute http or https URL",
+		codeInvalidWebhookEvents:  "Events must list at least one known event type or *",
+		codeDeliveryNotFound:      "Webhook delivery not found",
+		codeInvalidDeliveryStatus: "Status must be pending, succeeded or failed",
+		codeUnauthenticated:       "Authentication required",
+		codeForbidden:             "You do not have permission to do this",
+		codeInvalidCredentials:    "Invalid email or password",
+		codeInvalidEmail:          "A valid email is required",
+		codeEmailTaken:            "An account with this email already exists",
+		codePasswordTooShort:      "Password must be at least 8 characters long",
+		codeInvalidRole:           "Role must be customer, staff or admin",
+		codeUserNotFound:          "User not found",
+		codeNameRequired:          "Name is required",
+		codeAPIKeyNotFound:        "API key not found",
+		codeAPIKeyRevoked:         "API key has been revoked",
+		codeInvalidScopes:         "Scopes must list at least one known permission",
+		codeInvalidExpiry:         "Expiry date must be in the future",
+		codeInvalidDateRange:      "from and to must be YYYY-MM-DD dates with from not after to",
+		codeInvalidInterval:       "Interval must be day, week or month",
+		codeInvalidTaxClass:       "Tax class must be standard, reduced, super_reduced or zero",
+		codeInvalidRegion:         "Region must be a two-letter country code such as PL",
+		codeAddressNotFound:       "Address not found",
+		codeAddressIncomplete:     "Name, street, city and postal code are required",
+		codeInvalidCountry:        "Country must be a two-letter country code",
+		codeInvalidPostalCode:     "Postal code does not match the format of the country",
+		codeInvalidPhone:          "Phone must be 6 to 15 digits, optionally starting with +",
+	},
+}
+
+// Error returns the code, so validation results can be passed around as errors
+func (e errorCode) Error() string {
+	return string(e)
+}
+
+// Pick the best supported language from an Accept-Language header such as
+// "en-GB,en;q=0.9,pl;q=0.8"
+func negotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := errorMessages[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return defaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
+
+// Return the message for code in the language requested by the client
+func localizedMessage(c echo.Context, code errorCode) string {
+	lang := negotiateLanguage(c.Request().Header.Get("Accept-Language"))
+	if message, ok := errorMessages[lang][code]; ok {
+		return message
+	}
+	if message, ok := errorMessages[defaultLanguage][code]; ok {
+		return message
+	}
+	return string(code)
+}
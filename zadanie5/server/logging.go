@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type requestIDKey struct{}
+
+// Use a JSON slog handler on stdout as the default logger
+func setupLogging(level string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		l = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: l})))
+}
+
+// Return the request ID stored in ctx, or "" outside a request
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Return the default logger tagged with the request ID of ctx
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if id := requestID(ctx); id != "" {
+		return slog.Default().With("requestId", id)
+	}
+	return slog.Default()
+}
+
+// Accept client supplied IDs of up to 128 letters, digits, '-', '.' or '_'
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Assign a request ID (from X-Request-ID when valid), echo it back and log
+// one line per request
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		id := req.Header.Get(echo.HeaderXRequestID)
+		if !isValidRequestID(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+
+		start := time.Now()
+		err := next(c)
+		if err != nil {
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("requestId", id),
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.String("route", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("durationMs", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remoteIp", c.RealIP()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(req.Context(), level, "request", attrs...)
+
+		return err
+	}
+}
+
+// Write an error body with the code, its localized message and the request ID
+func errorJSON(c echo.Context, status int, code errorCode) error {
+	return c.JSON(status, apiError{
+		Code:      code,
+		Error:     localizedMessage(c, code),
+		RequestID: requestID(c.Request().Context()),
+	})
+}
+
+// Render Echo's own errors (unknown routes, bind failures) like handler errors
+func httpErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Code
+	}
+
+	if err := errorJSON(c, status, statusErrorCode(status)); err != nil {
+		loggerFrom(c.Request().Context()).Error("writing error response", "error", err)
+	}
+}
+
+// Pick the generic code for errors raised by Echo itself
+func statusErrorCode(status int) errorCode {
+	switch {
+	case status == http.StatusNotFound:
+		return codeNotFound
+	case status == http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case status == http.StatusTooManyRequests:
+		return codeTooManyRequests
+	case status >= http.StatusInternalServerError:
+		return codeInternal
+	}
+	return codeInvalidRequest
+}
+
+// GORM logger writing to slog with the request ID of the query's context;
+// queries are debug, slow ones warnings and failures errors; only the SQL
+// template is logged, never the bind values
+type gormLogger struct {
+	slowThreshold time.Duration
+	level         logger.LogLevel
+}
+
+// Switch the level GORM logs at
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	l.level = level
+	return l
+}
+
+// Log an informational GORM message
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		loggerFrom(ctx).Info(msg, "args", args)
+	}
+}
+
+// Log a GORM warning
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		loggerFrom(ctx).Warn(msg, "args", args)
+	}
+}
+
+// Log a GORM error
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		loggerFrom(ctx).Error(msg, "args", args)
+	}
+}
+
+// Drop bind values before GORM renders a statement for Trace so payment
+// and personal data stay out of the log
+func (l gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Log an executed query with its duration and affected rows
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error {
+		level = slog.LevelError
+	} else if l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn {
+		level = slog.LevelWarn
+	}
+
+	log := loggerFrom(ctx)
+	if !log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "durationMs", float64(elapsed.Microseconds()) / 1000}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	log.Log(ctx, level, "query", attrs...)
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	echo "github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 const (
@@ -19,43 +24,144 @@ type Product struct {
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
 	ImageURL    string  `json:"imageUrl"`
+	// Empty for the tax class of the category
+	TaxClass string `json:"taxClass"`
+
+	// Prices for the requested region, filled in when listing products
+	TaxRate      float64 `json:"taxRate" gorm:"-"`
+	NetPrice     float64 `json:"netPrice" gorm:"-"`
+	GrossPrice   float64 `json:"grossPrice" gorm:"-"`
+	DisplayPrice float64 `json:"displayPrice" gorm:"-"`
+	PriceMode    string  `json:"priceMode,omitempty" gorm:"-"`
 }
 
 type CartItem struct {
-	ID        uint    `json:"id" gorm:"primaryKey"`
-	ProductID uint    `json:"productId"`
-	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int     `json:"quantity"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"productId"`
+	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 type Payment struct {
-	ID         uint    `json:"id" gorm:"primaryKey"`
-	Amount     float64 `json:"amount"`
-	CardNumber string  `json:"cardNumber"`
-	CardHolder string  `json:"cardHolder"`
-	ExpiryDate string  `json:"expiryDate"`
-	CVV        string  `json:"cvv"`
-	Status     string  `json:"status"`
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Amount     float64   `json:"amount"`
+	CardNumber string    `json:"cardNumber"`
+	CardHolder string    `json:"cardHolder"`
+	ExpiryDate string    `json:"expiryDate"`
+	CVV        string    `json:"cvv"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+	// Tax region; Net and Tax sum the tax lines of the paid cart
+	Region   string           `json:"region"`
+	Net      float64          `json:"net"`
+	Tax      float64          `json:"tax"`
+	TaxLines []PaymentTaxLine `json:"taxLines"`
+	// Addresses as they were when paid; signed-in users may name entries of
+	// their address book instead, or rely on their defaults
+	ShippingAddress   PostalAddress `json:"shippingAddress" gorm:"embedded;embeddedPrefix:shipping_"`
+	BillingAddress    PostalAddress `json:"billingAddress" gorm:"embedded;embeddedPrefix:billing_"`
+	ShippingAddressID *uint         `json:"shippingAddressId,omitempty" gorm:"-"`
+	BillingAddressID  *uint         `json:"billingAddressId,omitempty" gorm:"-"`
+}
+
+// Payment as returned by the API: the card number is cut to its last four
+// digits and the CVV is never sent back
+type paymentResponse struct {
+	ID              uint             `json:"id"`
+	Amount          float64          `json:"amount"`
+	CardLast4       string           `json:"cardLast4"`
+	CardHolder      string           `json:"cardHolder"`
+	Status          string           `json:"status"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	Region          string           `json:"region"`
+	Net             float64          `json:"net"`
+	Tax             float64          `json:"tax"`
+	TaxLines        []PaymentTaxLine `json:"taxLines"`
+	ShippingAddress PostalAddress    `json:"shippingAddress"`
+	BillingAddress  PostalAddress    `json:"billingAddress"`
+}
+
+func newPaymentResponse(payment Payment) paymentResponse {
+	return paymentResponse{
+		ID:              payment.ID,
+		Amount:          payment.Amount,
+		CardLast4:       cardLast4(payment.CardNumber),
+		CardHolder:      payment.CardHolder,
+		Status:          payment.Status,
+		CreatedAt:       payment.CreatedAt,
+		Region:          payment.Region,
+		Net:             payment.Net,
+		Tax:             payment.Tax,
+		TaxLines:        payment.TaxLines,
+		ShippingAddress: payment.ShippingAddress,
+		BillingAddress:  payment.BillingAddress,
+	}
+}
+
+// Last four digits of a card number, ignoring spaces
+func cardLast4(number string) string {
+	number = strings.ReplaceAll(number, " ", "")
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
 }
 
 func main() {
+	setupLogging(os.Getenv("LOG_LEVEL"))
+
+	taxConfig = taxConfigFromEnv()
 	db := initializeDatabase()
 
-	e := setupServer()
+	if len(os.Args) > 1 {
+		runCommand(db, os.Args[1])
+		return
+	}
+
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		created, err := ensureAdmin(db, email, password)
+		if err != nil {
+			slog.Error("could not create admin user", "error", err)
+			os.Exit(1)
+		}
+		if created {
+			slog.Info("created admin user", "email", email)
+		}
+	}
+
+	go startCartCleanup(db, durationFromEnv("CART_CLEANUP_INTERVAL", time.Hour), durationFromEnv("CART_INACTIVITY_TTL", 72*time.Hour))
+	go webhookDispatcherFromEnv(db).start(durationFromEnv("WEBHOOK_INTERVAL", 5*time.Second))
+
+	e := setupServer(db)
 
 	registerRoutes(e, db)
 
-	e.Logger.Fatal(e.Start(":8080"))
+	if err := e.Start(":8080"); err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
 }
 
 func initializeDatabase() *gorm.DB {
-	db, err := gorm.Open(sqlite.Open("shop.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open("shop.db"), &gorm.Config{
+		Logger: gormLogger{slowThreshold: 200 * time.Millisecond, level: logger.Info},
+	})
 	if err != nil {
 		panic("failed to connect database")
 	}
 
-	db.AutoMigrate(&Product{}, &CartItem{}, &Payment{})
+	if err := instrumentDatabase(db); err != nil {
+		panic("failed to register database metrics")
+	}
+
+	db.AutoMigrate(&Product{}, &CartItem{}, &Payment{}, &PaymentItem{}, &PaymentTaxLine{}, &CategoryTaxClass{}, &Address{}, &CartAddition{}, &CartAbandonment{}, &WebhookSubscription{}, &WebhookEvent{}, &WebhookDelivery{}, &WebhookDeliveryAttempt{}, &User{}, &Session{}, &APIKey{})
+	if err := backfillCartTimestamps(db); err != nil {
+		slog.Error("backfilling cart timestamps", "error", err)
+	}
 	seedDatabaseIfEmpty(db)
 
 	return db
@@ -67,23 +173,30 @@ func seedDatabaseIfEmpty(db *gorm.DB) {
 
 	if count == 0 {
 		products := []Product{
-			{Name: "Laptop", Description: "Wydajny laptop dla programistów", Price: 3999.99, ImageURL: placeholderImageURL},
-			{Name: "Smartfon", Description: "Smartfon z najnowszym systemem", Price: 1999.99, ImageURL: placeholderImageURL},
-			{Name: "Słuchawki", Description: "Słuchawki z redukcją szumów", Price: 399.99, ImageURL: placeholderImageURL},
-			{Name: "Mysz komputerowa", Description: "Bezprzewodowa mysz ergonomiczna", Price: 149.99, ImageURL: placeholderImageURL},
+			{Name: "Laptop", Description: "Wydajny laptop dla programistów", Price: 3999.99, Category: "Komputery", ImageURL: placeholderImageURL},
+			{Name: "Smartfon", Description: "Smartfon z najnowszym systemem", Price: 1999.99, Category: "Telefony", ImageURL: placeholderImageURL},
+			{Name: "Słuchawki", Description: "Słuchawki z redukcją szumów", Price: 399.99, Category: "Akcesoria", ImageURL: placeholderImageURL},
+			{Name: "Mysz komputerowa", Description: "Bezprzewodowa mysz ergonomiczna", Price: 149.99, Category: "Akcesoria", ImageURL: placeholderImageURL},
 		}
 		db.Create(&products)
 	}
 }
 
 // Set up Echo server with middleware
-func setupServer() *echo.Echo {
+func setupServer(db *gorm.DB) *echo.Echo {
 	e := echo.New()
+	e.HideBanner = true
+	e.HTTPErrorHandler = httpErrorHandler
+	e.IPExtractor = ipExtractorFromEnv()
 
 	// Add middleware
-	e.Use(middleware.Logger())
+	e.Use(requestLogger)
+	e.Use(metricsMiddleware)
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		ExposeHeaders: []string{echo.HeaderXRequestID, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+	}))
+	e.Use(rateLimitMiddleware(rateLimitConfigFromEnv(db)))
 
 	return e
 }
@@ -107,128 +220,223 @@ func registerRoutes(e *echo.Echo, db *gorm.DB) {
 	// Payment endpoints
 	e.GET("/payments", func(c echo.Context) error {
 		return handleGetPayments(c, db)
-	})
+	}, requirePermission(db, permPaymentsRead))
 
 	e.POST("/payments", func(c echo.Context) error {
 		return handleCreatePayment(c, db)
 	})
+
+	// Webhook subscriptions and delivery log
+	registerWebhookRoutes(e, db)
+
+	// Accounts and role assignment
+	registerAuthRoutes(e, db)
+	registerAPIKeyRoutes(e, db)
+	registerAddressRoutes(e, db)
+
+	// VAT classes, rates and cart totals
+	registerTaxRoutes(e, db)
+
+	// Management reports
+	registerAnalyticsRoutes(e, db)
+
+	// API documentation
+	registerDocsRoutes(e)
+
+	// Monitoring
+	e.GET("/metrics", handleMetrics)
 }
 
 // Handle get products request
 func handleGetProducts(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
 	var products []Product
 	result := db.Find(&products)
 	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Nie udało się pobrać produktów"})
+		return errorJSON(c, http.StatusInternalServerError, codeProductsUnavailable)
+	}
+	if err := applyTaxPrices(db, products, requestRegion(c), c.QueryParam("prices")); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeProductsUnavailable)
 	}
 	return c.JSON(http.StatusOK, products)
 }
 
 // Handle get cart request
 func handleGetCart(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
 	var cartItems []CartItem
 	result := db.Preload("Product").Find(&cartItems)
 	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Nie udało się pobrać koszyka"})
+		return errorJSON(c, http.StatusInternalServerError, codeCartUnavailable)
 	}
 	return c.JSON(http.StatusOK, cartItems)
 }
 
 // Handle add to cart request
 func handleAddToCart(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
 	cartItem := new(CartItem)
 	if err := c.Bind(cartItem); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Nieprawidłowe dane"})
+		return errorJSON(c, http.StatusBadRequest, codeInvalidCartItem)
 	}
 
 	if !productExists(db, cartItem.ProductID) {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Produkt nie istnieje"})
+		return errorJSON(c, http.StatusNotFound, codeProductNotFound)
 	}
 
 	var product Product
 	db.First(&product, cartItem.ProductID)
 	cartItem.Product = product
 
-	result := db.Create(&cartItem)
-	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Nie udało się dodać do koszyka"})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&cartItem).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&CartAddition{ProductID: cartItem.ProductID, Quantity: cartItem.Quantity}).Error; err != nil {
+			return err
+		}
+		return enqueueWebhook(tx, eventCartItemAdded, cartItem)
+	})
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeCartAddFailed)
 	}
+	cartItemsAddedTotal.Inc()
 
 	return c.JSON(http.StatusCreated, cartItem)
 }
 
 // Handle get payments request
 func handleGetPayments(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
 	var payments []Payment
-	result := db.Find(&payments)
+	result := db.Preload("TaxLines").Find(&payments)
 	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Nie udało się pobrać historii płatności"})
+		return errorJSON(c, http.StatusInternalServerError, codePaymentsUnavailable)
+	}
+
+	response := make([]paymentResponse, len(payments))
+	for i, payment := range payments {
+		response[i] = newPaymentResponse(payment)
 	}
-	return c.JSON(http.StatusOK, payments)
+	return c.JSON(http.StatusOK, response)
 }
 
 // Handle create payment request
 func handleCreatePayment(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
 	payment := new(Payment)
 	if err := c.Bind(payment); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Nieprawidłowe dane płatności"})
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, http.StatusBadRequest, codeInvalidPayment)
+	}
+
+	if code := validatePayment(payment); code != "" {
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, http.StatusBadRequest, code)
 	}
 
-	if err := validatePayment(payment); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	payment.Region = strings.ToUpper(strings.TrimSpace(payment.Region))
+	if payment.Region == "" {
+		payment.Region = requestRegion(c)
+	} else if !regionPattern.MatchString(payment.Region) {
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRegion)
+	}
+
+	if status, code := applyPaymentAddresses(c, db, payment); code != "" {
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, status, code)
 	}
 
 	payment.Status = "completed"
-	result := db.Create(&payment)
-	if result.Error != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Błąd przetwarzania płatności"})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := applyPaymentTax(tx, payment); err != nil {
+			return err
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		if err := recordPaymentItems(tx, payment.ID); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM cart_items").Error; err != nil {
+			return err
+		}
+		return enqueueWebhook(tx, eventPaymentCompleted, paymentEvent{
+			ID:        payment.ID,
+			Amount:    payment.Amount,
+			Status:    payment.Status,
+			CardLast4: cardLast4(payment.CardNumber),
+		})
+	})
+	if errors.Is(err, errPaymentAmountMismatch) {
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, http.StatusBadRequest, codePaymentAmountMismatch)
 	}
+	if err != nil {
+		paymentsTotal.WithLabelValues("failed").Inc()
+		return errorJSON(c, http.StatusInternalServerError, codePaymentFailed)
+	}
+	paymentsTotal.WithLabelValues("completed").Inc()
+	revenueTotal.Add(payment.Amount)
 
-	db.Exec("DELETE FROM cart_items")
+	return c.JSON(http.StatusCreated, newPaymentResponse(*payment))
+}
 
-	return c.JSON(http.StatusCreated, payment)
+// Copy the cart into the items of a payment before it is emptied
+func recordPaymentItems(tx *gorm.DB, paymentID uint) error {
+	var cartItems []CartItem
+	if err := tx.Preload("Product").Find(&cartItems).Error; err != nil {
+		return err
+	}
+	if len(cartItems) == 0 {
+		return nil
+	}
+
+	items := make([]PaymentItem, len(cartItems))
+	for i, item := range cartItems {
+		items[i] = PaymentItem{PaymentID: paymentID, ProductID: item.ProductID, Quantity: item.Quantity, Price: item.Product.Price}
+	}
+	return tx.Create(&items).Error
 }
 
-// Validate payment details
-func validatePayment(payment *Payment) error {
+// Validate payment details, returning the code of the first problem or ""
+func validatePayment(payment *Payment) errorCode {
 	if payment.CardNumber == "" {
-		return errorStrings("Numer karty jest wymagany")
+		return codeCardNumberRequired
 	}
 
 	cardNumber := strings.ReplaceAll(payment.CardNumber, " ", "")
 	if len(cardNumber) != 16 || !isNumeric(cardNumber) {
-		return errorStrings("Nieprawidłowy format numeru karty")
+		return codeInvalidCardNumber
 	}
 
 	if payment.CardHolder == "" {
-		return errorStrings("Imię i nazwisko jest wymagane")
+		return codeCardHolderRequired
 	}
 
 	if payment.ExpiryDate == "" {
-		return errorStrings("Data ważności jest wymagana")
+		return codeExpiryDateRequired
 	} else if !isValidExpiryDate(payment.ExpiryDate) {
-		return errorStrings("Nieprawidłowy format daty")
+		return codeInvalidExpiryDate
 	}
 
 	if payment.CVV == "" {
-		return errorStrings("Kod CVV jest wymagany")
+		return codeCVVRequired
 	} else if len(payment.CVV) < 3 || len(payment.CVV) > 4 || !isNumeric(payment.CVV) {
-		return errorStrings("Nieprawidłowy format CVV")
+		return codeInvalidCVV
 	}
 
 	if payment.Amount <= 0 {
-		return errorStrings("Kwota płatności jest nieprawidłowa")
+		return codeInvalidAmount
 	}
 
-	return nil
-}
-
-// Helper type for string errors
-type errorStrings string
-
-// Error returns the error string
-func (e errorStrings) Error() string {
-	return string(e)
+	return ""
 }
 
 // Check if a product exists in the database
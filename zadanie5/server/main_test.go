@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	e := echo.New()
+	registerRoutes(e, nil)
+
+	routes := map[string]bool{}
+	for _, route := range e.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+
+	documented := map[string]bool{}
+	for _, op := range apiOperations {
+		key := op.Method + " " + op.Path
+		documented[key] = true
+		if !routes[key] {
+			t.Errorf("%s is documented but not registered", key)
+		}
+	}
+
+	for route := range routes {
+		if !documented[route] {
+			t.Errorf("%s is registered but missing from apiOperations", route)
+		}
+	}
+}
+
+func TestOpenAPISpecSchemas(t *testing.T) {
+	body, err := json.Marshal(buildOpenAPISpec(apiOperations))
+	if err != nil {
+		t.Fatalf("Failed to marshal spec: %s", err)
+	}
+
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &spec); err != nil {
+		t.Fatalf("Failed to decode spec: %s", err)
+	}
+
+	product, ok := spec.Components.Schemas["Product"]
+	if !ok {
+		t.Fatalf("Expected Product schema")
+	}
+	for _, field := range []string{"id", "name", "description", "price", "imageUrl"} {
+		if _, ok := product.Properties[field]; !ok {
+			t.Errorf("Expected Product.%s in schema", field)
+		}
+	}
+}
+
+func TestAnalyticsReports(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	if err := db.AutoMigrate(&Product{}, &Payment{}, &PaymentItem{}, &CartAddition{}, &CartAbandonment{}); err != nil {
+		t.Fatalf("Failed to migrate: %s", err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, time.March, d, 12, 0, 0, 0, time.UTC) }
+	db.Create(&[]Product{
+		{ID: 1, Name: "Laptop", Price: 100, Category: "Komputery"},
+		{ID: 2, Name: "Mysz", Price: 10, Category: "Akcesoria"},
+	})
+	db.Create(&[]CartAddition{
+		{ProductID: 1, Quantity: 1, CreatedAt: day(1)},
+		{ProductID: 2, Quantity: 2, CreatedAt: day(1)},
+		{ProductID: 2, Quantity: 1, CreatedAt: day(2)},
+		{ProductID: 1, Quantity: 1, CreatedAt: day(20)},
+	})
+	db.Create(&[]Payment{
+		{ID: 1, Amount: 120, Status: "completed", CreatedAt: day(1)},
+		{ID: 2, Amount: 10, Status: "completed", CreatedAt: day(2)},
+		{ID: 3, Amount: 100, Status: "completed", CreatedAt: day(20)},
+	})
+	db.Create(&[]PaymentItem{
+		{PaymentID: 1, ProductID: 1, Quantity: 1, Price: 100},
+		{PaymentID: 1, ProductID: 2, Quantity: 2, Price: 10},
+		{PaymentID: 2, ProductID: 2, Quantity: 1, Price: 10},
+	})
+	db.Create(&CartAbandonment{Value: 50, CreatedAt: day(2)})
+
+	r := reportRange{from: day(1).Truncate(24 * time.Hour), to: day(3).Truncate(24 * time.Hour)}
+
+	revenue, err := revenueReport(db, r, "day")
+	if err != nil {
+		t.Fatalf("revenueReport: %s", err)
+	}
+	if len(revenue) != 2 || revenue[0].Period != "2024-03-01" || revenue[0].Revenue != 120 || revenue[1].Revenue != 10 {
+		t.Errorf("Unexpected revenue by day: %+v", revenue)
+	}
+
+	top, err := topProductsReport(db, r, 10)
+	if err != nil {
+		t.Fatalf("topProductsReport: %s", err)
+	}
+	if len(top) != 2 || top[0].ProductID != 2 || top[0].Additions != 2 || top[0].Quantity != 3 {
+		t.Errorf("Unexpected top products: %+v", top)
+	}
+
+	carts, err := cartSummary(db, r)
+	if err != nil {
+		t.Fatalf("cartSummary: %s", err)
+	}
+	if carts.Carts != 3 || carts.PaidCarts != 2 || carts.AverageCartValue != 65 {
+		t.Errorf("Unexpected cart report: %+v", carts)
+	}
+
+	categories, err := categoryReport(db, r)
+	if err != nil {
+		t.Fatalf("categoryReport: %s", err)
+	}
+	byName := map[string]categoryRow{}
+	for _, row := range categories {
+		byName[row.Category] = row
+	}
+	if got := byName["Akcesoria"]; got.Additions != 2 || got.QuantitySold != 3 || got.Revenue != 30 {
+		t.Errorf("Unexpected Akcesoria performance: %+v", got)
+	}
+	if got := byName["Komputery"]; got.Additions != 1 || got.QuantitySold != 1 || got.Revenue != 100 {
+		t.Errorf("Unexpected Komputery performance: %+v", got)
+	}
+}
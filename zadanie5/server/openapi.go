@@ -0,0 +1,216 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+)
+
+// API operation documented in the OpenAPI spec. Request and Response are
+// sample values whose JSON shape becomes the schema.
+type apiOperation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  interface{}
+	Response interface{}
+	Status   int
+	// Permission the caller's role needs; empty for public routes
+	Permission permission
+}
+
+type apiError struct {
+	Code      errorCode `json:"code"`
+	Error     string    `json:"error"`
+	RequestID string    `json:"requestId"`
+}
+
+// Every route registered by registerRoutes; main_test.go checks they match
+var apiOperations = []apiOperation{
+	{Method: http.MethodGet, Path: "/products", Summary: "List products with prices taxed for ?region (or X-Region), shown as ?prices=net|gross", Response: []Product{}},
+	{Method: http.MethodGet, Path: "/cart", Summary: "List cart items", Response: []CartItem{}},
+	{Method: http.MethodGet, Path: "/cart/totals", Summary: "Net, tax and gross cart totals with tax lines for ?region (or X-Region)", Response: cartTotals{}},
+	{Method: http.MethodPost, Path: "/cart", Summary: "Add a product to the cart", Request: CartItem{}, Response: CartItem{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/payments", Summary: "List payments, with only the last four card digits", Response: []paymentResponse{}, Permission: permPaymentsRead},
+	{Method: http.MethodPost, Path: "/payments", Summary: "Pay for the cart, shipped and billed to the given, named or default addresses", Request: Payment{}, Response: paymentResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/openapi.json", Summary: "This OpenAPI document"},
+	{Method: http.MethodGet, Path: "/docs", Summary: "API reference page"},
+	{Method: http.MethodGet, Path: "/metrics", Summary: "Prometheus metrics"},
+	{Method: http.MethodPost, Path: "/webhooks", Summary: "Subscribe a URL to events; the response holds the signing secret", Request: webhookRequest{}, Response: WebhookSubscription{}, Status: http.StatusCreated, Permission: permWebhooksManage},
+	{Method: http.MethodGet, Path: "/webhooks", Summary: "List webhook subscriptions", Response: []WebhookSubscription{}, Permission: permWebhooksManage},
+	{Method: http.MethodGet, Path: "/webhooks/events", Summary: "List event types", Response: []string{}, Permission: permWebhooksManage},
+	{Method: http.MethodPut, Path: "/webhooks/:id", Summary: "Update a webhook subscription", Request: webhookRequest{}, Response: WebhookSubscription{}, Permission: permWebhooksManage},
+	{Method: http.MethodDelete, Path: "/webhooks/:id", Summary: "Delete a webhook subscription", Status: http.StatusNoContent, Permission: permWebhooksManage},
+	{Method: http.MethodGet, Path: "/webhooks/:id/deliveries", Summary: "Delivery log with attempts", Response: []WebhookDelivery{}, Permission: permWebhooksManage},
+	{Method: http.MethodPost, Path: "/webhooks/:id/deliveries/:deliveryId/redeliver", Summary: "Send a delivery again", Response: WebhookDelivery{}, Status: http.StatusAccepted, Permission: permWebhooksManage},
+	{Method: http.MethodPost, Path: "/auth/register", Summary: "Create a customer account", Request: registerRequest{}, Response: User{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/auth/login", Summary: "Exchange credentials for a bearer token", Request: loginRequest{}, Response: loginResponse{}},
+	{Method: http.MethodPost, Path: "/auth/logout", Summary: "Revoke the bearer token", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/auth/me", Summary: "The authenticated account", Response: User{}},
+	{Method: http.MethodGet, Path: "/addresses", Summary: "The signed-in user's address book, defaults first", Response: []Address{}},
+	{Method: http.MethodPost, Path: "/addresses", Summary: "Add an address; the first one is the default for shipping and billing", Request: addressRequest{}, Response: Address{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/addresses/:id", Summary: "Get an address", Response: Address{}},
+	{Method: http.MethodPut, Path: "/addresses/:id", Summary: "Replace an address or make it a default", Request: addressRequest{}, Response: Address{}},
+	{Method: http.MethodDelete, Path: "/addresses/:id", Summary: "Delete an address", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/admin/users", Summary: "List accounts, optionally filtered by ?role", Response: []User{}, Permission: permUsersManage},
+	{Method: http.MethodPut, Path: "/admin/users/:id/role", Summary: "Assign a role (customer, staff or admin)", Request: roleRequest{}, Response: User{}, Permission: permUsersManage},
+	{Method: http.MethodPost, Path: "/admin/api-keys", Summary: "Issue an API key; the response holds the key", Request: apiKeyRequest{}, Response: APIKey{}, Status: http.StatusCreated, Permission: permAPIKeysManage},
+	{Method: http.MethodGet, Path: "/admin/api-keys", Summary: "List API keys with last-used times, ?active=true for usable ones", Response: []APIKey{}, Permission: permAPIKeysManage},
+	{Method: http.MethodGet, Path: "/admin/api-keys/scopes", Summary: "List permissions a key can be scoped to", Response: []permission{}, Permission: permAPIKeysManage},
+	{Method: http.MethodPut, Path: "/admin/api-keys/:id", Summary: "Rename an API key or change its scopes and expiry", Request: apiKeyRequest{}, Response: APIKey{}, Permission: permAPIKeysManage},
+	{Method: http.MethodDelete, Path: "/admin/api-keys/:id", Summary: "Revoke an API key", Response: APIKey{}, Permission: permAPIKeysManage},
+	{Method: http.MethodPost, Path: "/admin/api-keys/:id/rotate", Summary: "Replace an API key's value; the response holds the new key", Response: APIKey{}, Permission: permAPIKeysManage},
+	{Method: http.MethodGet, Path: "/tax/rates", Summary: "VAT rates per region and tax class", Response: map[string]map[string]float64{}},
+	{Method: http.MethodGet, Path: "/tax/categories", Summary: "Tax classes assigned to categories", Response: []CategoryTaxClass{}},
+	{Method: http.MethodPut, Path: "/tax/categories/:category", Summary: "Set the tax class of a category", Request: taxClassRequest{}, Response: CategoryTaxClass{}, Permission: permTaxManage},
+	{Method: http.MethodPut, Path: "/products/:id/tax-class", Summary: "Set a product's tax class; empty uses the category's", Request: taxClassRequest{}, Response: Product{}, Permission: permTaxManage},
+	{Method: http.MethodGet, Path: "/analytics/revenue", Summary: "Revenue per ?interval=day|week|month between ?from and ?to; ?format=csv for CSV", Response: []revenueRow{}, Permission: permAnalyticsRead},
+	{Method: http.MethodGet, Path: "/analytics/top-products", Summary: "Top ?limit products by cart additions between ?from and ?to; ?format=csv for CSV", Response: []topProductRow{}, Permission: permAnalyticsRead},
+	{Method: http.MethodGet, Path: "/analytics/carts", Summary: "Cart to payment conversion and average cart value between ?from and ?to; ?format=csv for CSV", Response: cartReport{}, Permission: permAnalyticsRead},
+	{Method: http.MethodGet, Path: "/analytics/categories", Summary: "Additions, units sold and revenue per category between ?from and ?to; ?format=csv for CSV", Response: []categoryRow{}, Permission: permAnalyticsRead},
+}
+
+const docsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Shop API</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="/openapi.json"></redoc>
+    <script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+  </body>
+</html>
+`
+
+// Register the OpenAPI document and the Redoc page rendering it
+func registerDocsRoutes(e *echo.Echo) {
+	spec := buildOpenAPISpec(apiOperations)
+
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, spec)
+	})
+
+	e.GET("/docs", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, docsPage)
+	})
+}
+
+// Build an OpenAPI 3 document from the documented operations
+func buildOpenAPISpec(operations []apiOperation) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorSchema := schemaFor(reflect.TypeOf(apiError{}), schemas)
+
+	paths := map[string]interface{}{}
+	for _, op := range operations {
+		item, ok := paths[op.Path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		if op.Response != nil {
+			success["content"] = jsonContent(schemaFor(reflect.TypeOf(op.Response), schemas))
+		}
+
+		operation := map[string]interface{}{
+			"summary": op.Summary,
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): success,
+				"default": map[string]interface{}{
+					"description": "Error",
+					"content":     jsonContent(errorSchema),
+				},
+			},
+		}
+		if op.Permission != "" {
+			operation["description"] = "Requires the " + string(op.Permission) + " permission."
+			operation["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemaFor(reflect.TypeOf(op.Request), schemas)),
+			}
+		}
+
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Shop API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// Wrap a schema as an application/json content entry
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// Describe a Go type the way encoding/json serialises it
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), schemas)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Struct:
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{}
+			properties := map[string]interface{}{}
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				tag := strings.Split(field.Tag.Get("json"), ",")[0]
+				if tag == "-" || !field.IsExported() {
+					continue
+				}
+				if tag == "" {
+					tag = field.Name
+				}
+				properties[tag] = schemaFor(field.Type, schemas)
+			}
+			schemas[name] = map[string]interface{}{"type": "object", "properties": properties}
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
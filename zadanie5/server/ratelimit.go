@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Token bucket size and refill period, e.g. 60 requests per minute
+type rateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// State of a bucket after taking a token from it
+type rateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Storage for token buckets; swap the in-memory one for a shared store when
+// running more than one server
+type rateLimitStore interface {
+	Take(key string, limit rateLimit, now time.Time) (rateLimitResult, error)
+}
+
+// Limits applied by rateLimitMiddleware. Every client gets one bucket keyed
+// by a valid API key or else by IP, plus one per route listed in Routes
+// ("POST /payments"). ValidateAPIKey returns the ID of a valid key; keys it
+// rejects, or all keys when it is nil, count against the IP
+type rateLimitConfig struct {
+	Store          rateLimitStore
+	IP             rateLimit
+	APIKey         rateLimit
+	Routes         map[string]rateLimit
+	ValidateAPIKey func(c echo.Context, key string) (id string, ok bool)
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// In-process token buckets; idle buckets are dropped once a minute
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// Create an empty in-memory store
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}}
+}
+
+// Refill the bucket for key and take one token from it if available
+func (s *memoryRateLimitStore) Take(key string, limit rateLimit, now time.Time) (rateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= time.Minute {
+		s.lastSweep = now
+		for k, b := range s.buckets {
+			if now.Sub(b.updated) > b.period {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Period.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.period = limit.Period
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	result := rateLimitResult{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	return result, nil
+}
+
+// Reject clients over their limits with 429 and report the bucket closest
+// to running out in RateLimit-* headers
+func rateLimitMiddleware(cfg rateLimitConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			now := time.Now()
+
+			client, limit := "ip:"+c.RealIP(), cfg.IP
+			if key := c.Request().Header.Get(apiKeyHeader); key != "" && cfg.ValidateAPIKey != nil {
+				if id, ok := cfg.ValidateAPIKey(c, key); ok {
+					client, limit = "key:"+id, cfg.APIKey
+				}
+			}
+
+			result, err := cfg.Store.Take(client, limit, now)
+			if err != nil {
+				loggerFrom(c.Request().Context()).Error("rate limit store", "error", err)
+				return next(c)
+			}
+
+			route := c.Request().Method + " " + c.Path()
+			if routeLimit, ok := cfg.Routes[route]; ok {
+				routeResult, err := cfg.Store.Take(client+"|"+route, routeLimit, now)
+				if err != nil {
+					loggerFrom(c.Request().Context()).Error("rate limit store", "error", err)
+					return next(c)
+				}
+				if result.Allowed && (!routeResult.Allowed || routeResult.Remaining < result.Remaining) {
+					result = routeResult
+				}
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				return errorJSON(c, http.StatusTooManyRequests, codeTooManyRequests)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Parse a limit written as "requests/period", e.g. "60/1m"
+func parseRateLimit(value string) (rateLimit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return rateLimit{}, fmt.Errorf("invalid rate limit %q, expected requests/period", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return rateLimit{}, fmt.Errorf("invalid request count in rate limit %q", value)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return rateLimit{}, fmt.Errorf("invalid period in rate limit %q", value)
+	}
+
+	return rateLimit{Requests: n, Period: d}, nil
+}
+
+// Read a limit from the environment, falling back when unset or invalid
+func rateLimitFromEnv(name, fallback string) rateLimit {
+	if value := os.Getenv(name); value != "" {
+		limit, err := parseRateLimit(value)
+		if err == nil {
+			return limit
+		}
+		slog.Warn("invalid rate limit in environment", "name", name, "error", err, "fallback", fallback)
+	}
+
+	limit, _ := parseRateLimit(fallback)
+	return limit
+}
+
+// Build the limits from RATE_LIMIT_IP, RATE_LIMIT_API_KEY and
+// RATE_LIMIT_ROUTES ("POST /payments=5/1m,POST /cart=30/1m")
+func rateLimitConfigFromEnv(db *gorm.DB) rateLimitConfig {
+	cfg := rateLimitConfig{
+		Store:  newMemoryRateLimitStore(),
+		IP:     rateLimitFromEnv("RATE_LIMIT_IP", "120/1m"),
+		APIKey: rateLimitFromEnv("RATE_LIMIT_API_KEY", "600/1m"),
+		Routes: map[string]rateLimit{},
+		// The principal is cached on the context, so handlers do not
+		// look the key up again
+		ValidateAPIKey: func(c echo.Context, key string) (string, bool) {
+			p, err := authenticate(c, db)
+			if err != nil || p.apiKey == nil {
+				return "", false
+			}
+			return strconv.FormatUint(uint64(p.apiKey.ID), 10), true
+		},
+	}
+
+	routes := os.Getenv("RATE_LIMIT_ROUTES")
+	if routes == "" {
+		routes = "POST /payments=5/1m,POST /cart=30/1m"
+	}
+	for _, entry := range strings.Split(routes, ",") {
+		route, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			slog.Warn("invalid RATE_LIMIT_ROUTES entry", "entry", entry)
+			continue
+		}
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			slog.Warn("invalid RATE_LIMIT_ROUTES entry", "entry", entry, "error", err)
+			continue
+		}
+		cfg.Routes[strings.Join(strings.Fields(route), " ")] = limit
+	}
+
+	return cfg
+}
+
+// Client IP source for c.RealIP(): X-Forwarded-For is only read from the
+// proxies in TRUSTED_PROXIES ("10.0.0.0/8,172.16.0.0/12"), otherwise the
+// peer address is used so clients cannot pick their rate limit bucket
+func ipExtractorFromEnv() echo.IPExtractor {
+	proxies := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if proxies == "" {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range strings.Split(proxies, ",") {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			slog.Warn("invalid TRUSTED_PROXIES entry", "entry", cidr, "error", err)
+			continue
+		}
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
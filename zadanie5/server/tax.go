@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// VAT classes; products without one use their category's, then standard
+const (
+	taxClassStandard     = "standard"
+	taxClassReduced      = "reduced"
+	taxClassSuperReduced = "super_reduced"
+	taxClassZero         = "zero"
+	priceModeGross       = "gross"
+	priceModeNet         = "net"
+	regionHeader         = "X-Region"
+)
+
+// VAT rates in percent per region and tax class
+var taxRates = map[string]map[string]float64{
+	"PL": {taxClassStandard: 23, taxClassReduced: 8, taxClassSuperReduced: 5, taxClassZero: 0},
+	"DE": {taxClassStandard: 19, taxClassReduced: 7, taxClassSuperReduced: 7, taxClassZero: 0},
+	"CZ": {taxClassStandard: 21, taxClassReduced: 12, taxClassSuperReduced: 12, taxClassZero: 0},
+}
+
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+var errPaymentAmountMismatch = errors.New("payment amount does not match the cart total")
+
+// How catalogue prices are taxed, read from TAX_REGION, PRICES_INCLUDE_TAX
+// and PRICE_DISPLAY
+type taxSettings struct {
+	region           string
+	pricesIncludeTax bool
+	displayMode      string
+}
+
+var taxConfig = taxSettings{region: "PL", pricesIncludeTax: true, displayMode: priceModeGross}
+
+// Tax class of the products of a category that have none of their own
+type CategoryTaxClass struct {
+	Category string `json:"category" gorm:"primaryKey"`
+	TaxClass string `json:"taxClass"`
+}
+
+// Net, tax and gross amounts charged at one rate
+type TaxLine struct {
+	TaxClass string  `json:"taxClass"`
+	Rate     float64 `json:"rate"`
+	Net      float64 `json:"net"`
+	Tax      float64 `json:"tax"`
+	Gross    float64 `json:"gross"`
+}
+
+// Tax line of a payment, kept as it was charged
+type PaymentTaxLine struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	PaymentID uint `json:"paymentId" gorm:"index"`
+	TaxLine
+}
+
+type cartTotals struct {
+	Region   string    `json:"region"`
+	Net      float64   `json:"net"`
+	Tax      float64   `json:"tax"`
+	Gross    float64   `json:"gross"`
+	TaxLines []TaxLine `json:"taxLines"`
+}
+
+type taxClassRequest struct {
+	TaxClass string `json:"taxClass"`
+}
+
+func taxConfigFromEnv() taxSettings {
+	config := taxConfig
+	if region := strings.ToUpper(os.Getenv("TAX_REGION")); regionPattern.MatchString(region) {
+		config.region = region
+	}
+	if os.Getenv("PRICES_INCLUDE_TAX") == "false" {
+		config.pricesIncludeTax = false
+	}
+	if mode := os.Getenv("PRICE_DISPLAY"); mode == priceModeNet || mode == priceModeGross {
+		config.displayMode = mode
+	}
+	return config
+}
+
+func registerTaxRoutes(e *echo.Echo, db *gorm.DB) {
+	manageTax := requirePermission(db, permTaxManage)
+	e.GET("/tax/rates", handleGetTaxRates)
+	e.GET("/tax/categories", func(c echo.Context) error {
+		return handleGetCategoryTaxClasses(c, db)
+	})
+	e.PUT("/tax/categories/:category", func(c echo.Context) error {
+		return handleSetCategoryTaxClass(c, db)
+	}, manageTax)
+	e.PUT("/products/:id/tax-class", func(c echo.Context) error {
+		return handleSetProductTaxClass(c, db)
+	}, manageTax)
+	e.GET("/cart/totals", func(c echo.Context) error {
+		return handleGetCartTotals(c, db)
+	})
+}
+
+// Region from ?region or the X-Region header, else the default one
+func requestRegion(c echo.Context) string {
+	region := c.QueryParam("region")
+	if region == "" {
+		region = c.Request().Header.Get(regionHeader)
+	}
+	if region = strings.ToUpper(strings.TrimSpace(region)); regionPattern.MatchString(region) {
+		return region
+	}
+	return taxConfig.region
+}
+
+// Rate of a tax class in a region; regions without rates use the default one
+func taxRate(class, region string) float64 {
+	rates, ok := taxRates[region]
+	if !ok {
+		rates = taxRates[taxConfig.region]
+	}
+	if rate, ok := rates[class]; ok {
+		return rate
+	}
+	return rates[taxClassStandard]
+}
+
+func isTaxClass(class string) bool {
+	_, ok := taxRates[taxConfig.region][class]
+	return ok
+}
+
+// Tax class of every category that has one
+func categoryTaxClasses(db *gorm.DB) (map[string]string, error) {
+	var rows []CategoryTaxClass
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	classes := make(map[string]string, len(rows))
+	for _, row := range rows {
+		classes[row.Category] = row.TaxClass
+	}
+	return classes, nil
+}
+
+func productTaxClass(product Product, categories map[string]string) string {
+	if product.TaxClass != "" {
+		return product.TaxClass
+	}
+	if class := categories[product.Category]; class != "" {
+		return class
+	}
+	return taxClassStandard
+}
+
+// Split a catalogue amount into its net, tax and gross parts
+func splitTax(amount, rate float64) (net, tax, gross float64) {
+	if taxConfig.pricesIncludeTax {
+		gross = roundCents(amount)
+		net = roundCents(amount * 100 / (100 + rate))
+		return net, roundCents(gross - net), gross
+	}
+	net = roundCents(amount)
+	tax = roundCents(amount * rate / 100)
+	return net, tax, roundCents(net + tax)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Fill in the taxed prices of products for a region in the display mode of
+// ?prices=net|gross
+func applyTaxPrices(db *gorm.DB, products []Product, region, mode string) error {
+	categories, err := categoryTaxClasses(db)
+	if err != nil {
+		return err
+	}
+	if mode != priceModeNet && mode != priceModeGross {
+		mode = taxConfig.displayMode
+	}
+
+	for i := range products {
+		p := &products[i]
+		p.TaxRate = taxRate(productTaxClass(*p, categories), region)
+		p.NetPrice, _, p.GrossPrice = splitTax(p.Price, p.TaxRate)
+		p.PriceMode = mode
+		p.DisplayPrice = p.GrossPrice
+		if mode == priceModeNet {
+			p.DisplayPrice = p.NetPrice
+		}
+	}
+	return nil
+}
+
+// Group cart items by tax class and tax each group, highest rate first
+func cartTaxLines(db *gorm.DB, region string) ([]TaxLine, error) {
+	var cartItems []CartItem
+	if err := db.Preload("Product").Find(&cartItems).Error; err != nil {
+		return nil, err
+	}
+	categories, err := categoryTaxClasses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := map[string]float64{}
+	for _, item := range cartItems {
+		amounts[productTaxClass(item.Product, categories)] += item.Product.Price * float64(item.Quantity)
+	}
+
+	lines := make([]TaxLine, 0, len(amounts))
+	for class, amount := range amounts {
+		line := TaxLine{TaxClass: class, Rate: taxRate(class, region)}
+		line.Net, line.Tax, line.Gross = splitTax(amount, line.Rate)
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Rate != lines[j].Rate {
+			return lines[i].Rate > lines[j].Rate
+		}
+		return lines[i].TaxClass < lines[j].TaxClass
+	})
+	return lines, nil
+}
+
+func sumTaxLines(lines []TaxLine) (net, tax, gross float64) {
+	for _, line := range lines {
+		net += line.Net
+		tax += line.Tax
+		gross += line.Gross
+	}
+	return roundCents(net), roundCents(tax), roundCents(gross)
+}
+
+// Set the region and tax lines of a payment from the cart, which must add
+// up to the amount paid; a payment without a cart is a gross amount taxed
+// at the standard rate
+func applyPaymentTax(tx *gorm.DB, payment *Payment) error {
+	lines, err := cartTaxLines(tx, payment.Region)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		line := TaxLine{TaxClass: taxClassStandard, Rate: taxRate(taxClassStandard, payment.Region)}
+		line.Gross = roundCents(payment.Amount)
+		line.Net = roundCents(payment.Amount * 100 / (100 + line.Rate))
+		line.Tax = roundCents(line.Gross - line.Net)
+		lines = append(lines, line)
+	}
+
+	net, tax, gross := sumTaxLines(lines)
+	if math.Abs(payment.Amount-gross) >= 0.005 {
+		return errPaymentAmountMismatch
+	}
+
+	payment.TaxLines = make([]PaymentTaxLine, len(lines))
+	for i, line := range lines {
+		payment.TaxLines[i] = PaymentTaxLine{TaxLine: line}
+	}
+	payment.Amount, payment.Net, payment.Tax = gross, net, tax
+	return nil
+}
+
+func handleGetTaxRates(c echo.Context) error {
+	return c.JSON(http.StatusOK, taxRates)
+}
+
+func handleGetCategoryTaxClasses(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	rows := []CategoryTaxClass{}
+	if err := db.Order("category").Find(&rows).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, rows)
+}
+
+func handleSetCategoryTaxClass(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(taxClassRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if !isTaxClass(req.TaxClass) {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidTaxClass)
+	}
+
+	row := CategoryTaxClass{Category: c.Param("category"), TaxClass: req.TaxClass}
+	if err := db.Save(&row).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, row)
+}
+
+// An empty taxClass makes the product use its category's class again
+func handleSetProductTaxClass(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(taxClassRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if req.TaxClass != "" && !isTaxClass(req.TaxClass) {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidTaxClass)
+	}
+
+	var product Product
+	if err := db.First(&product, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeProductNotFound)
+	}
+	if err := db.Model(&product).Update("tax_class", req.TaxClass).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, product)
+}
+
+func handleGetCartTotals(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	totals := cartTotals{Region: requestRegion(c)}
+	lines, err := cartTaxLines(db, totals.Region)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeCartUnavailable)
+	}
+	totals.TaxLines = lines
+	totals.Net, totals.Tax, totals.Gross = sumTaxLines(lines)
+	return c.JSON(http.StatusOK, totals)
+}
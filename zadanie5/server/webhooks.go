@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	echo "github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	eventCartItemAdded     = "cart.item_added"
+	eventPaymentCompleted  = "payment.completed"
+	deliveryPending        = "pending"
+	deliverySucceeded      = "succeeded"
+	deliveryFailed         = "failed"
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+// Event types a subscription can ask for; "*" matches all of them
+var webhookEventTypes = []string{eventCartItemAdded, eventPaymentCompleted}
+
+// Endpoint receiving the listed event types; Secret signs the deliveries and
+// is only returned when the subscription is created
+type WebhookSubscription struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events" gorm:"serializer:json"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Outbox entry written in the same transaction as the change it describes
+type WebhookEvent struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	Type         string          `json:"type" gorm:"index"`
+	Payload      json.RawMessage `json:"data"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	DispatchedAt *time.Time      `json:"dispatchedAt" gorm:"index"`
+}
+
+// Sending of one event to one subscription
+type WebhookDelivery struct {
+	ID             uint                     `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint                     `json:"subscriptionId" gorm:"index"`
+	EventID        uint                     `json:"eventId" gorm:"index"`
+	Event          WebhookEvent             `json:"event"`
+	Status         string                   `json:"status" gorm:"index"`
+	AttemptCount   int                      `json:"attemptCount"`
+	NextAttemptAt  *time.Time               `json:"nextAttemptAt" gorm:"index"`
+	DeliveredAt    *time.Time               `json:"deliveredAt"`
+	Attempts       []WebhookDeliveryAttempt `json:"attempts" gorm:"foreignKey:DeliveryID"`
+	CreatedAt      time.Time                `json:"createdAt"`
+	UpdatedAt      time.Time                `json:"updatedAt"`
+}
+
+// One HTTP request made for a delivery
+type WebhookDeliveryAttempt struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID   uint      `json:"deliveryId" gorm:"index"`
+	StatusCode   int       `json:"statusCode"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"durationMs"`
+	ResponseBody string    `json:"responseBody,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+type webhookEnvelope struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Payment as sent to webhooks, without card details
+type paymentEvent struct {
+	ID        uint    `json:"id"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+	CardLast4 string  `json:"cardLast4"`
+}
+
+// Retry policy and HTTP client used by the webhook dispatcher
+type webhookDispatcher struct {
+	db          *gorm.DB
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+// Add an event to the outbox within the transaction making the change
+func enqueueWebhook(tx *gorm.DB, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&WebhookEvent{Type: eventType, Payload: payload}).Error
+}
+
+// Check whether the subscription wants events of eventType
+func (s WebhookSubscription) matches(eventType string) bool {
+	for _, e := range s.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign "<timestamp>.<body>" with HMAC-SHA256 keyed by the subscription secret
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Wait base after the first failure, doubling up to max
+func webhookBackoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
+
+// Read an integer from the environment
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("invalid integer in environment", "name", name, "value", value, "fallback", fallback)
+		return fallback
+	}
+	return n
+}
+
+// Build the dispatcher from WEBHOOK_TIMEOUT, WEBHOOK_MAX_ATTEMPTS,
+// WEBHOOK_BACKOFF and WEBHOOK_MAX_BACKOFF
+func webhookDispatcherFromEnv(db *gorm.DB) *webhookDispatcher {
+	return &webhookDispatcher{
+		db:          db,
+		client:      &http.Client{Timeout: durationFromEnv("WEBHOOK_TIMEOUT", 10*time.Second)},
+		maxAttempts: intFromEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		baseBackoff: durationFromEnv("WEBHOOK_BACKOFF", 30*time.Second),
+		maxBackoff:  durationFromEnv("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+	}
+}
+
+// Run the dispatcher every interval
+func (d *webhookDispatcher) start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := d.run(context.Background()); err != nil {
+			slog.Error("dispatching webhooks", "error", err)
+		}
+	}
+}
+
+// Fan out new events to subscriptions and send the deliveries that are due
+func (d *webhookDispatcher) run(ctx context.Context) error {
+	db := d.db.WithContext(ctx)
+
+	var events []WebhookEvent
+	if err := db.Where("dispatched_at IS NULL").Order("id").Limit(100).Find(&events).Error; err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		var subscriptions []WebhookSubscription
+		if err := db.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := d.fanOut(db, event, subscriptions); err != nil {
+				return err
+			}
+		}
+	}
+
+	var deliveries []WebhookDelivery
+	err := db.Preload("Event").
+		Where("status = ? AND next_attempt_at <= ?", deliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(100).
+		Find(&deliveries).Error
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		if err := d.deliver(ctx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create a delivery per matching subscription and mark the event dispatched
+func (d *webhookDispatcher) fanOut(db *gorm.DB, event WebhookEvent, subscriptions []WebhookSubscription) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		claimed := tx.Model(&WebhookEvent{}).Where("id = ? AND dispatched_at IS NULL", event.ID).Update("dispatched_at", now)
+		if claimed.Error != nil || claimed.RowsAffected == 0 {
+			return claimed.Error
+		}
+
+		for _, subscription := range subscriptions {
+			if !subscription.matches(event.Type) {
+				continue
+			}
+			delivery := WebhookDelivery{SubscriptionID: subscription.ID, EventID: event.ID, Status: deliveryPending, NextAttemptAt: &now}
+			if err := tx.Create(&delivery).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Send one delivery and record the attempt, scheduling a retry on failure
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery *WebhookDelivery) error {
+	db := d.db.WithContext(ctx)
+
+	lease := time.Now().Add(d.client.Timeout + time.Minute)
+	claimed := db.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, deliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if claimed.Error != nil || claimed.RowsAffected == 0 {
+		return claimed.Error
+	}
+
+	attempt := WebhookDeliveryAttempt{DeliveryID: delivery.ID}
+	var subscription WebhookSubscription
+	if err := db.First(&subscription, delivery.SubscriptionID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		attempt.Error = "subscription no longer exists"
+	} else if !subscription.Active {
+		attempt.Error = "subscription is inactive"
+	} else {
+		d.send(ctx, subscription, delivery, &attempt)
+	}
+
+	now := time.Now()
+	delivery.AttemptCount++
+	switch {
+	case attempt.Error == "":
+		delivery.Status = deliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.AttemptCount >= d.maxAttempts || !subscription.Active:
+		delivery.Status = deliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(webhookBackoff(delivery.AttemptCount, d.baseBackoff, d.maxBackoff))
+		delivery.NextAttemptAt = &next
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Select("status", "attempt_count", "next_attempt_at", "delivered_at").Updates(delivery).Error
+	})
+}
+
+// POST the signed event to the subscription URL
+func (d *webhookDispatcher) send(ctx context.Context, subscription WebhookSubscription, delivery *WebhookDelivery, attempt *WebhookDeliveryAttempt) {
+	body, err := json.Marshal(webhookEnvelope{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(webhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhookSignatureHeader, signWebhook(subscription.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	attempt.ResponseBody = string(snippet)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// Check the URL and event types of a subscription request
+func validateWebhook(req *webhookRequest) errorCode {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return codeInvalidWebhookURL
+	}
+	if len(req.Events) == 0 {
+		return codeInvalidWebhookEvents
+	}
+	for _, event := range req.Events {
+		known := event == "*"
+		for _, t := range webhookEventTypes {
+			known = known || t == event
+		}
+		if !known {
+			return codeInvalidWebhookEvents
+		}
+	}
+	return ""
+}
+
+// Register the webhook subscription and delivery log endpoints
+func registerWebhookRoutes(e *echo.Echo, db *gorm.DB) {
+	manage := requirePermission(db, permWebhooksManage)
+	e.POST("/webhooks", func(c echo.Context) error {
+		return handleCreateWebhook(c, db)
+	}, manage)
+	e.GET("/webhooks", func(c echo.Context) error {
+		return handleGetWebhooks(c, db)
+	}, manage)
+	e.GET("/webhooks/events", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, webhookEventTypes)
+	}, manage)
+	e.PUT("/webhooks/:id", func(c echo.Context) error {
+		return handleUpdateWebhook(c, db)
+	}, manage)
+	e.DELETE("/webhooks/:id", func(c echo.Context) error {
+		return handleDeleteWebhook(c, db)
+	}, manage)
+	e.GET("/webhooks/:id/deliveries", func(c echo.Context) error {
+		return handleGetWebhookDeliveries(c, db)
+	}, manage)
+	e.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", func(c echo.Context) error {
+		return handleRedeliverWebhook(c, db)
+	}, manage)
+}
+
+// Handle create webhook request; the response is the only one with the secret
+func handleCreateWebhook(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	req := new(webhookRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if code := validateWebhook(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	subscription := WebhookSubscription{URL: req.URL, Events: req.Events, Secret: req.Secret, Active: req.Active == nil || *req.Active}
+	if subscription.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return errorJSON(c, http.StatusInternalServerError, codeInternal)
+		}
+		subscription.Secret = "whsec_" + hex.EncodeToString(b)
+	}
+
+	if err := db.Create(&subscription).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusCreated, subscription)
+}
+
+// Handle list webhooks request
+func handleGetWebhooks(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	subscriptions := []WebhookSubscription{}
+	if err := db.Order("id").Find(&subscriptions).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+// Handle update webhook request; a non-empty secret rotates it
+func handleUpdateWebhook(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var subscription WebhookSubscription
+	if err := db.First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeWebhookNotFound)
+	}
+
+	req := new(webhookRequest)
+	if err := c.Bind(req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if code := validateWebhook(req); code != "" {
+		return errorJSON(c, http.StatusBadRequest, code)
+	}
+
+	subscription.URL = req.URL
+	subscription.Events = req.Events
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := db.Save(&subscription).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	subscription.Secret = ""
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// Handle delete webhook request
+func handleDeleteWebhook(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	result := db.Delete(&WebhookSubscription{}, c.Param("id"))
+	if result.Error != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	if result.RowsAffected == 0 {
+		return errorJSON(c, http.StatusNotFound, codeWebhookNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Handle delivery log request, newest first, optionally filtered by ?status=
+func handleGetWebhookDeliveries(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var subscription WebhookSubscription
+	if err := db.First(&subscription, c.Param("id")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeWebhookNotFound)
+	}
+
+	query := db.Preload("Event").Preload("Attempts").Where("subscription_id = ?", subscription.ID)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case deliveryPending, deliverySucceeded, deliveryFailed:
+		query = query.Where("status = ?", status)
+	default:
+		return errorJSON(c, http.StatusBadRequest, codeInvalidDeliveryStatus)
+	}
+
+	deliveries := []WebhookDelivery{}
+	if err := query.Order("id DESC").Limit(100).Find(&deliveries).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// Handle redeliver request by queueing the delivery with a fresh attempt budget
+func handleRedeliverWebhook(c echo.Context, db *gorm.DB) error {
+	db = db.WithContext(c.Request().Context())
+
+	var delivery WebhookDelivery
+	if err := db.Preload("Event").Where("subscription_id = ?", c.Param("id")).First(&delivery, c.Param("deliveryId")).Error; err != nil {
+		return errorJSON(c, http.StatusNotFound, codeDeliveryNotFound)
+	}
+
+	now := time.Now()
+	delivery.Status = deliveryPending
+	delivery.AttemptCount = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+	if err := db.Model(&delivery).Select("status", "attempt_count", "next_attempt_at", "delivered_at").Updates(&delivery).Error; err != nil {
+		return errorJSON(c, http.StatusInternalServerError, codeInternal)
+	}
+	return c.JSON(http.StatusAccepted, delivery)
+}